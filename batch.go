@@ -0,0 +1,104 @@
+package gocky
+
+import (
+	"context"
+	"sync"
+)
+
+// Parser parses many sentences against one grammar, indexing the grammar once rather than for every sentence
+// A Parser reuses its chart rows between sentences, so it is not safe for use by multiple goroutines.
+type Parser struct {
+	parser *parser
+}
+
+// NewParser creates a Parser for the grammar
+func NewParser(grammar Grammar) *Parser {
+	return &Parser{parser: newParser(indexGrammar(grammar))}
+}
+
+// Parses produces the same parses as Parses
+func (p *Parser) Parses(words []string) []Parse {
+	parses, _ := p.parser.parse(context.Background(), words, ParseOptions{})
+	return parses
+}
+
+// ParsesContext produces the same parses as ParsesContext
+func (p *Parser) ParsesContext(ctx context.Context, words []string, options ParseOptions) ([]Parse, error) {
+	return p.parser.parse(ctx, words, options)
+}
+
+// BatchResult holds the parses for one sentence of a batch
+// Index is the position of the sentence in the input channel, since results arrive in the order they finish.
+type BatchResult struct {
+	Index  int
+	Parses []Parse
+	Err    error
+}
+
+// batchSentence is a sentence waiting to be parsed, tagged with its position in the batch
+type batchSentence struct {
+	index int
+	words []string
+}
+
+// ParseBatch parses every sentence received from sentences against the same grammar, using the given number of workers.
+// The grammar is indexed once and each worker reuses its chart between sentences.
+// Every sentence is parsed with the options, so a *LimitError is reported in the result of the sentence that exceeded a limit.
+// Results are sent as they finish, and the returned channel is closed once sentences is closed and drained.
+// Once the context is done the workers stop, and the returned channel is closed without a result for any sentence it interrupted.
+// Results sent before then may still be waiting in the channel.
+func ParseBatch(ctx context.Context, sentences <-chan []string, grammar Grammar, workers int, options ParseOptions) <-chan BatchResult {
+	if workers < 1 {
+		workers = 1
+	}
+	index := indexGrammar(grammar)
+	results := make(chan BatchResult, workers)
+	jobs := make(chan batchSentence)
+
+	go func() {
+		defer close(jobs)
+		sentenceIndex := 0
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case words, ok := <-sentences:
+				if !ok {
+					return
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case jobs <- batchSentence{index: sentenceIndex, words: words}:
+				}
+				sentenceIndex++
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < workers; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p := newParser(index)
+			for job := range jobs {
+				parses, err := p.parse(ctx, job.words, options)
+				if ctx.Err() != nil {
+					return
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case results <- BatchResult{Index: job.index, Parses: parses, Err: err}:
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+	return results
+}
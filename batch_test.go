@@ -0,0 +1,123 @@
+package gocky
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestParser(t *testing.T) {
+	parser := NewParser(panda())
+	sentences := []string{"the panda eats shoots and leaves", "the panda eats", "", "the shoots and leaves eats shoots and leaves"}
+	for _, sentence := range sentences {
+		words := strings.Fields(sentence)
+		expectedParses := Parses(words, panda())
+		actualParses := parser.Parses(words)
+		if len(actualParses) != len(expectedParses) {
+			t.Fatalf("(Test \"%s\"), num parses expected %d, got %d", sentence, len(expectedParses), len(actualParses))
+		}
+		for parseIndex := range expectedParses {
+			if !expectedParses[parseIndex].Equal(&actualParses[parseIndex]) {
+				t.Errorf("(Test \"%s\"), expected parse %s, got %s", sentence, expectedParses[parseIndex].String(), actualParses[parseIndex].String())
+			}
+		}
+	}
+	if _, err := parser.ParsesContext(context.Background(), strings.Fields(sentences[0]), ParseOptions{MaxWords: 3}); err == nil {
+		t.Errorf("Expected a limit error from the parser")
+	}
+}
+
+func TestParseBatch(t *testing.T) {
+	sentences := []string{
+		"the panda eats shoots and leaves",
+		"the panda eats",
+		"panda the eats",
+		"the shoots and leaves eats shoots and leaves",
+		"the panda shoots and leaves",
+	}
+
+	for workers := 1; workers <= 3; workers++ {
+		input := make(chan []string)
+		go func() {
+			defer close(input)
+			for _, sentence := range sentences {
+				input <- regexp.MustCompile("\\s+").Split(sentence, -1)
+			}
+		}()
+
+		seen := map[int]bool{}
+		for result := range ParseBatch(context.Background(), input, panda(), workers, ParseOptions{}) {
+			if result.Err != nil {
+				t.Fatalf("(Workers %d), unexpected error %v", workers, result.Err)
+			}
+			if seen[result.Index] {
+				t.Errorf("(Workers %d), sentence %d reported twice", workers, result.Index)
+			}
+			seen[result.Index] = true
+
+			words := regexp.MustCompile("\\s+").Split(sentences[result.Index], -1)
+			expectedParses := Parses(words, panda())
+			if len(expectedParses) != len(result.Parses) {
+				t.Fatalf("(Workers %d, Test \"%s\"), num parses expected %d, got %d", workers, sentences[result.Index], len(expectedParses), len(result.Parses))
+			}
+			for parseIndex := range expectedParses {
+				expectedProductionKeys := expectedParses[parseIndex].ProductionKeys()
+				actualProductionKeys := result.Parses[parseIndex].ProductionKeys()
+				if !reflect.DeepEqual(expectedProductionKeys, actualProductionKeys) {
+					t.Errorf("(Workers %d, Test \"%s\"), expected production keys %v, but got %v", workers, sentences[result.Index], expectedProductionKeys, actualProductionKeys)
+				}
+			}
+		}
+		if len(seen) != len(sentences) {
+			t.Errorf("(Workers %d), expected %d results, got %d", workers, len(sentences), len(seen))
+		}
+	}
+}
+
+func TestParseBatchCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	input := make(chan []string)
+	results := ParseBatch(ctx, input, panda(), 2, ParseOptions{})
+	cancel()
+	for range results {
+	}
+
+	// Sentences already waiting when the context is done must not produce results, not even context errors
+	waiting := make(chan []string, 10)
+	for sentence := 0; sentence < cap(waiting); sentence++ {
+		waiting <- []string{"the", "panda", "eats", "shoots", "and", "leaves"}
+	}
+	close(waiting)
+	for result := range ParseBatch(ctx, waiting, panda(), 3, ParseOptions{}) {
+		t.Errorf("Expected no results once the context is done, got sentence %d with error %v", result.Index, result.Err)
+	}
+}
+
+func TestParseBatchOptions(t *testing.T) {
+	sentences := [][]string{
+		{"the", "panda", "eats", "shoots", "and", "leaves"},
+		{"the", "panda", "eats"},
+	}
+	input := make(chan []string, len(sentences))
+	for _, words := range sentences {
+		input <- words
+	}
+	close(input)
+
+	for result := range ParseBatch(context.Background(), input, panda(), 2, ParseOptions{MaxWords: 3}) {
+		var limitErr *LimitError
+		switch result.Index {
+		case 0:
+			if !errors.As(result.Err, &limitErr) || limitErr.Limit != LimitWords {
+				t.Errorf("Expected a max words limit error for the long sentence, got %v", result.Err)
+			}
+		case 1:
+			if result.Err != nil || len(result.Parses) != 1 {
+				t.Errorf("Expected 1 parse for the short sentence, got %d and %v", len(result.Parses), result.Err)
+			}
+		}
+	}
+}
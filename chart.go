@@ -0,0 +1,52 @@
+package gocky
+
+import "context"
+
+// Chart holds every parse built while parsing a sentence, by the span of words each covers
+// The parses spanning the whole sentence are the ones Parses returns.
+type Chart struct {
+	words []string
+	table [][][]Parse
+}
+
+// BuildChart parses the words and returns the whole CKY chart, rather than only the complete parses
+func BuildChart(words []string, grammar Grammar) *Chart {
+	chart, _ := BuildChartContext(context.Background(), words, grammar, ParseOptions{})
+	return chart
+}
+
+// BuildChartContext builds the chart like BuildChart, but gives up like ParsesContext
+func BuildChartContext(ctx context.Context, words []string, grammar Grammar, options ParseOptions) (*Chart, error) {
+	p := newParser(indexGrammar(grammar))
+	if _, err := p.parse(ctx, words, options); err != nil {
+		return nil, err
+	}
+	return &Chart{words: append([]string{}, words...), table: p.table}, nil
+}
+
+// Words returns the words the chart was built for
+func (c *Chart) Words() []string {
+	return append([]string{}, c.words...)
+}
+
+// Len returns the number of words the chart was built for
+func (c *Chart) Len() int {
+	return len(c.words)
+}
+
+// Cell returns the parses spanning the words from start up to, but not including, end
+// Spans outside the sentence have no parses.
+func (c *Chart) Cell(start int, end int) []Parse {
+	if start < 0 || end > len(c.words) || start >= end {
+		return nil
+	}
+	return c.table[start][end]
+}
+
+// Parses returns the parses spanning every word, the same parses Parses returns
+func (c *Chart) Parses() []Parse {
+	if len(c.words) == 0 {
+		return []Parse{}
+	}
+	return c.table[0][len(c.words)]
+}
@@ -0,0 +1,61 @@
+package gocky
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestBuildChart(t *testing.T) {
+	words := []string{"book", "that", "flight"}
+	chart := BuildChart(words, bookFlight())
+
+	if chart.Len() != 3 || !reflect.DeepEqual(chart.Words(), words) {
+		t.Fatalf("Expected chart for %v, got %v", words, chart.Words())
+	}
+
+	type test struct {
+		start        int
+		end          int
+		expectedKeys []string
+	}
+
+	testCases := []test{
+		{start: 0, end: 1, expectedKeys: []string{"N", "V"}},
+		{start: 1, end: 2, expectedKeys: []string{"DT"}},
+		{start: 0, end: 2, expectedKeys: []string{}},
+		{start: 1, end: 3, expectedKeys: []string{"NP"}},
+		{start: 0, end: 3, expectedKeys: []string{"VP"}},
+		{start: 2, end: 2, expectedKeys: []string{}},
+		{start: 0, end: 4, expectedKeys: []string{}},
+	}
+
+	for _, testCase := range testCases {
+		keys := []string{}
+		for _, parse := range chart.Cell(testCase.start, testCase.end) {
+			keys = append(keys, parse.Key())
+		}
+		if !reflect.DeepEqual(keys, testCase.expectedKeys) {
+			t.Errorf("(Test \"%d-%d\"), expected keys %v, got %v", testCase.start, testCase.end, testCase.expectedKeys, keys)
+		}
+	}
+
+	parses := Parses(words, bookFlight())
+	if len(chart.Parses()) != len(parses) || !chart.Parses()[0].Equal(&parses[0]) {
+		t.Errorf("Expected the chart's parses to match Parses, got %v", chart.Parses())
+	}
+}
+
+func TestBuildChartContext(t *testing.T) {
+	_, err := BuildChartContext(context.Background(), []string{"book", "that", "flight"}, bookFlight(), ParseOptions{MaxWords: 2})
+	var limitErr *LimitError
+	if !errors.As(err, &limitErr) || limitErr.Limit != LimitWords {
+		t.Errorf("Expected a max words LimitError, got %v", err)
+	}
+
+	chart, err := BuildChartContext(context.Background(), []string{}, bookFlight(), ParseOptions{})
+	if err != nil || chart.Len() != 0 || len(chart.Parses()) != 0 {
+		t.Errorf("Expected an empty chart, got %v %v", chart, err)
+	}
+}
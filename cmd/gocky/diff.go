@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/kstafford3/gocky"
+)
+
+// runDiff prints the differences between two grammars, then the corpus sentences whose parse counts differ
+// Each sentence is printed with its parse count under the old and new grammars:
+//
+//	"book that flight": 1 -> 2
+//
+// The exit status is exitDifferent when anything differs.
+func runDiff(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("diff", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	oldPath := flags.String("old", "", "grammar file before the change")
+	newPath := flags.String("new", "", "grammar file after the change")
+	corpusPath := flags.String("corpus", "", "file of sentences to parse with both grammars, one per line, \"-\" for standard input")
+	startKeys := flags.String("start", "", "comma separated keys a parse must start from, any key when empty")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if len(*oldPath) == 0 || len(*newPath) == 0 {
+		fmt.Fprintln(stderr, "gocky diff: -old and -new are required")
+		return exitUsage
+	}
+	oldGrammar, err := gocky.LoadGrammar(*oldPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "gocky diff: %v\n", err)
+		return exitFailure
+	}
+	newGrammar, err := gocky.LoadGrammar(*newPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "gocky diff: %v\n", err)
+		return exitFailure
+	}
+
+	status := exitOK
+	diff := gocky.Diff(oldGrammar, newGrammar)
+	if !diff.Empty() {
+		status = exitDifferent
+	}
+	if err := diff.Write(stdout); err != nil {
+		fmt.Fprintf(stderr, "gocky diff: %v\n", err)
+		return exitFailure
+	}
+	if len(*corpusPath) == 0 {
+		return status
+	}
+
+	sentences, err := readCorpus(*corpusPath, stdin)
+	if err != nil {
+		fmt.Fprintf(stderr, "gocky diff: %v\n", err)
+		return exitFailure
+	}
+	keys := splitKeys(*startKeys)
+	oldParser, newParser := gocky.NewParser(oldGrammar), gocky.NewParser(newGrammar)
+	for _, sentence := range sentences {
+		words := tokenize(sentence)
+		oldCount := len(matchingParses(oldParser, words, keys))
+		newCount := len(matchingParses(newParser, words, keys))
+		if oldCount != newCount {
+			fmt.Fprintf(stdout, "%q: %d -> %d\n", sentence, oldCount, newCount)
+			status = exitDifferent
+		}
+	}
+	return status
+}
+
+// readCorpus reads the non-empty lines of the corpus file, or of stdin when the path is "-"
+func readCorpus(path string, stdin io.Reader) ([]string, error) {
+	reader := stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		reader = file
+	}
+	sentences := []string{}
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		if len(strings.TrimSpace(scanner.Text())) > 0 {
+			sentences = append(sentences, scanner.Text())
+		}
+	}
+	return sentences, scanner.Err()
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRunDiff(t *testing.T) {
+	oldPath := writeGrammarFile(t, "old.gky", bookFlightGrammar)
+	newPath := writeGrammarFile(t, "new.gky", `
+DT -> "the" | "a"
+N  -> "book" | "flight"
+V  -> "book" | "flight"
+NP -> DT N
+VP -> V NP
+`)
+	corpusPath := writeGrammarFile(t, "corpus.txt", "book that flight\nbook the flight\n\nflight the book\n")
+
+	type test struct {
+		name           string
+		args           []string
+		stdin          string
+		expectedStatus int
+		expectedOutput string
+		expectedError  string
+	}
+
+	testCases := []test{
+		{
+			name:           "same",
+			args:           []string{"-old", oldPath, "-new", oldPath, "-corpus", corpusPath},
+			expectedStatus: exitOK,
+		},
+		{
+			name:           "grammar",
+			args:           []string{"-old", oldPath, "-new", newPath},
+			expectedStatus: exitDifferent,
+			expectedOutput: "- DT -> \"that\"\n+ V -> \"flight\"\n",
+		},
+		{
+			name:           "corpus",
+			args:           []string{"-old", oldPath, "-new", newPath, "-corpus", corpusPath},
+			expectedStatus: exitDifferent,
+			expectedOutput: "- DT -> \"that\"\n+ V -> \"flight\"\n\"book that flight\": 1 -> 0\n\"flight the book\": 0 -> 1\n",
+		},
+		{
+			name:           "corpus from stdin with start keys",
+			args:           []string{"-old", oldPath, "-new", newPath, "-corpus", "-", "-start", "NP"},
+			stdin:          "that book\nthe book\n",
+			expectedStatus: exitDifferent,
+			expectedOutput: "- DT -> \"that\"\n+ V -> \"flight\"\n\"that book\": 1 -> 0\n",
+		},
+		{
+			name:           "missing grammar",
+			args:           []string{"-old", oldPath},
+			expectedStatus: exitUsage,
+			expectedError:  "-old and -new are required",
+		},
+		{
+			name:           "unreadable corpus",
+			args:           []string{"-old", oldPath, "-new", newPath, "-corpus", corpusPath + ".missing"},
+			expectedStatus: exitFailure,
+			expectedError:  "no such file",
+		},
+	}
+
+	for _, testCase := range testCases {
+		stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+		status := runDiff(testCase.args, strings.NewReader(testCase.stdin), stdout, stderr)
+		if status != testCase.expectedStatus {
+			t.Errorf("(Test \"%s\"), expected status %d, got %d", testCase.name, testCase.expectedStatus, status)
+		}
+		if len(testCase.expectedError) == 0 && stdout.String() != testCase.expectedOutput {
+			t.Errorf("(Test \"%s\"), expected output %q, got %q", testCase.name, testCase.expectedOutput, stdout.String())
+		}
+		if !strings.Contains(stderr.String(), testCase.expectedError) {
+			t.Errorf("(Test \"%s\"), expected error containing %q, got %q", testCase.name, testCase.expectedError, stderr.String())
+		}
+	}
+}
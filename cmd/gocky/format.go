@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/kstafford3/gocky"
+)
+
+// format writes the parses of a sentence
+type format func(writer io.Writer, sentence string, parses []gocky.Parse) error
+
+// formats maps the names accepted by -format to their writers
+var formats = map[string]format{
+	"bracketed": writeBracketed,
+	"ascii":     writeASCII,
+	"json":      writeJSON,
+}
+
+// writeBracketed writes one bracketed parse per line
+func writeBracketed(writer io.Writer, sentence string, parses []gocky.Parse) error {
+	for parseIndex := range parses {
+		if _, err := fmt.Fprintln(writer, parses[parseIndex].String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeASCII draws each parse as an indented tree, separated by blank lines
+func writeASCII(writer io.Writer, sentence string, parses []gocky.Parse) error {
+	builder := &strings.Builder{}
+	for parseIndex := range parses {
+		if parseIndex > 0 {
+			builder.WriteString("\n")
+		}
+		drawNode(builder, &parses[parseIndex], "", "")
+	}
+	_, err := io.WriteString(writer, builder.String())
+	return err
+}
+
+// drawNode draws a node and its components, prefixing the node's line and its components' lines
+func drawNode(builder *strings.Builder, node *gocky.Parse, linePrefix string, childPrefix string) {
+	builder.WriteString(linePrefix + node.Key())
+	if node.Left() == nil && node.Right() == nil {
+		builder.WriteString(" " + node.Terminal())
+	}
+	builder.WriteString("\n")
+	children := []*gocky.Parse{}
+	for _, child := range []*gocky.Parse{node.Left(), node.Right()} {
+		if child != nil {
+			children = append(children, child)
+		}
+	}
+	for childIndex, child := range children {
+		if childIndex == len(children)-1 {
+			drawNode(builder, child, childPrefix+"└── ", childPrefix+"    ")
+		} else {
+			drawNode(builder, child, childPrefix+"├── ", childPrefix+"│   ")
+		}
+	}
+}
+
+// jsonTree is the JSON form of a node, with the span of words it covers
+type jsonTree struct {
+	Key      string      `json:"key"`
+	Start    int         `json:"start"`
+	End      int         `json:"end"`
+	Word     string      `json:"word,omitempty"`
+	Children []*jsonTree `json:"children,omitempty"`
+}
+
+// newJSONTree converts a parse into its JSON form
+func newJSONTree(parse *gocky.Parse) *jsonTree {
+	spans := map[*gocky.Parse]*jsonTree{}
+	for _, leaf := range parse.Leaves() {
+		spans[leaf.Node] = &jsonTree{Key: leaf.Node.Key(), Start: leaf.Start, End: leaf.End, Word: leaf.Node.Terminal()}
+	}
+	gocky.WalkPostOrder(parse, func(node *gocky.Parse, depth int) gocky.WalkAction {
+		if _, ok := spans[node]; ok {
+			return gocky.WalkContinue
+		}
+		tree := &jsonTree{Key: node.Key()}
+		for _, child := range []*gocky.Parse{node.Left(), node.Right()} {
+			if child != nil {
+				tree.Children = append(tree.Children, spans[child])
+			}
+		}
+		tree.Start = tree.Children[0].Start
+		tree.End = tree.Children[len(tree.Children)-1].End
+		spans[node] = tree
+		return gocky.WalkContinue
+	})
+	return spans[parse]
+}
+
+// jsonSentence is the JSON form of a sentence and its parses
+type jsonSentence struct {
+	Sentence string      `json:"sentence"`
+	Parses   []*jsonTree `json:"parses"`
+}
+
+// writeJSON writes one JSON object per sentence, holding every parse
+func writeJSON(writer io.Writer, sentence string, parses []gocky.Parse) error {
+	output := jsonSentence{Sentence: sentence, Parses: []*jsonTree{}}
+	for parseIndex := range parses {
+		output.Parses = append(output.Parses, newJSONTree(&parses[parseIndex]))
+	}
+	return json.NewEncoder(writer).Encode(output)
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/kstafford3/gocky"
+)
+
+func TestFormats(t *testing.T) {
+	grammar := gocky.Grammar{
+		gocky.TerminalProduction("DT", []string{"that"}),
+		gocky.TerminalProduction("N", []string{"flight"}),
+		gocky.TerminalProduction("V", []string{"book"}),
+		gocky.NonterminalProduction("NP", "DT", "N"),
+		gocky.NonterminalProduction("VP", "V", "NP"),
+	}
+	parses := gocky.Parses([]string{"book", "that", "flight"}, grammar)
+
+	type test struct {
+		format         string
+		expectedOutput string
+	}
+
+	testCases := []test{
+		{
+			format:         "bracketed",
+			expectedOutput: "(VP (V book) (NP (DT that) (N flight)))\n",
+		},
+		{
+			format: "ascii",
+			expectedOutput: "VP\n" +
+				"├── V book\n" +
+				"└── NP\n" +
+				"    ├── DT that\n" +
+				"    └── N flight\n",
+		},
+		{
+			format: "json",
+			expectedOutput: `{"sentence":"book that flight","parses":[{"key":"VP","start":0,"end":3,"children":[` +
+				`{"key":"V","start":0,"end":1,"word":"book"},` +
+				`{"key":"NP","start":1,"end":3,"children":[{"key":"DT","start":1,"end":2,"word":"that"},{"key":"N","start":2,"end":3,"word":"flight"}]}` +
+				`]}]}` + "\n",
+		},
+	}
+
+	for _, testCase := range testCases {
+		output := &bytes.Buffer{}
+		if err := formats[testCase.format](output, "book that flight", parses); err != nil {
+			t.Fatalf("(Test \"%s\"), unexpected error %v", testCase.format, err)
+		}
+		if output.String() != testCase.expectedOutput {
+			t.Errorf("(Test \"%s\"), expected output %q, got %q", testCase.format, testCase.expectedOutput, output.String())
+		}
+	}
+}
@@ -0,0 +1,82 @@
+// Command gocky parses sentences against gocky grammar files.
+//
+// Usage:
+//
+//	gocky parse -grammar grammar.gky [-start S,NP] [-format bracketed|ascii|json] [sentence ...]
+//	gocky repl -grammar grammar.gky [-start S,NP]
+//	gocky serve -grammar name=grammar.gky [-grammar other=other.gky] [-addr localhost:8080] [-timeout 10s] [-max-words 200]
+//	gocky diff -old old.gky -new new.gky [-corpus sentences.txt] [-start S,NP]
+//	gocky profile -grammar grammar.gky [-corpus sentences.txt] [-top 10]
+//
+// The parse command reads sentences from the arguments, or one per line from standard input when there are none.
+// The repl command parses sentences as they are typed, and takes commands that edit and save the grammar.
+// The serve command answers JSON parse requests over HTTP: POST /parse parses a sentence with a named grammar,
+// GET /grammars lists the grammars being served, POST /reload reloads their files and GET /health reports that the server is up.
+// The diff command lists the productions that differ between two grammars, and the corpus sentences whose parse counts differ.
+// The profile command parses a corpus and reports which productions do the most work and cause the most ambiguity.
+// Grammar files use the format described by gocky.ReadGrammar.
+//
+// The exit status is 0 on success, and 1 when parse finds a sentence with no parse or diff finds a difference.
+// Usage errors exit with 2, and failures while running, such as a grammar or corpus that cannot be read, exit with 3.
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// Exit statuses shared by the subcommands
+const (
+	exitOK        = 0
+	exitNoParse   = 1
+	exitDifferent = 1
+	exitUsage     = 2
+	exitFailure   = 3
+)
+
+// command is a subcommand of gocky
+type command struct {
+	name        string
+	description string
+	run         func(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int
+}
+
+// commands lists the subcommands of gocky
+var commands = []command{
+	{name: "parse", description: "parse sentences against a grammar", run: runParse},
+	{name: "repl", description: "parse sentences interactively while editing a grammar", run: runREPL},
+	{name: "serve", description: "serve parses over an HTTP JSON API", run: runServe},
+	{name: "diff", description: "compare two grammars and the parses they give a corpus", run: runDiff},
+	{name: "profile", description: "report the work and ambiguity of a grammar over a corpus", run: runProfile},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run dispatches to the subcommand named by the first argument
+func run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return exitUsage
+	}
+	for _, command := range commands {
+		if command.name == args[0] {
+			return command.run(args[1:], stdin, stdout, stderr)
+		}
+	}
+	fmt.Fprintf(stderr, "gocky: unknown command %q\n", args[0])
+	usage(stderr)
+	return exitUsage
+}
+
+// usage lists the subcommands
+func usage(writer io.Writer) {
+	fmt.Fprintln(writer, "usage: gocky <command> [arguments]")
+	fmt.Fprintln(writer)
+	fmt.Fprintln(writer, "commands:")
+	for _, command := range commands {
+		fmt.Fprintf(writer, "  %-8s %s\n", command.name, command.description)
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// bookFlightGrammar is the book flight grammar in the gocky grammar format
+const bookFlightGrammar = `
+DT -> "the" | "that" | "a"
+N  -> "book" | "flight"
+V  -> "book"
+NP -> DT N
+VP -> V NP
+`
+
+// writeGrammarFile writes a grammar to a temporary file and returns its path
+func writeGrammarFile(t *testing.T, name string, grammar string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(grammar), 0o644); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	return path
+}
+
+func TestRun(t *testing.T) {
+	type test struct {
+		name           string
+		args           []string
+		expectedStatus int
+		expectedError  string
+	}
+
+	testCases := []test{
+		{name: "no command", args: []string{}, expectedStatus: exitUsage, expectedError: "usage: gocky"},
+		{name: "unknown command", args: []string{"frobnicate"}, expectedStatus: exitUsage, expectedError: "unknown command"},
+	}
+
+	for _, testCase := range testCases {
+		stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+		status := run(testCase.args, strings.NewReader(""), stdout, stderr)
+		if status != testCase.expectedStatus {
+			t.Errorf("(Test \"%s\"), expected status %d, got %d", testCase.name, testCase.expectedStatus, status)
+		}
+		if !strings.Contains(stderr.String(), testCase.expectedError) {
+			t.Errorf("(Test \"%s\"), expected error containing %q, got %q", testCase.name, testCase.expectedError, stderr.String())
+		}
+	}
+}
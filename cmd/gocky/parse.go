@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/kstafford3/gocky"
+	"github.com/kstafford3/gocky/tokenizer"
+)
+
+// runParse parses each sentence and prints its parses
+// The exit status is exitNoParse when any sentence has no parses.
+func runParse(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("parse", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	grammarPath := flags.String("grammar", "", "grammar file to parse with")
+	startKeys := flags.String("start", "", "comma separated keys a parse must start from, any key when empty")
+	formatName := flags.String("format", "bracketed", "output format: bracketed, ascii or json")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if len(*grammarPath) == 0 {
+		fmt.Fprintln(stderr, "gocky parse: -grammar is required")
+		return exitUsage
+	}
+	format, ok := formats[*formatName]
+	if !ok {
+		fmt.Fprintf(stderr, "gocky parse: unknown format %q\n", *formatName)
+		return exitUsage
+	}
+	grammar, err := gocky.LoadGrammar(*grammarPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "gocky parse: %v\n", err)
+		return exitFailure
+	}
+
+	sentences := flags.Args()
+	if len(sentences) == 0 {
+		scanner := bufio.NewScanner(stdin)
+		for scanner.Scan() {
+			if len(strings.TrimSpace(scanner.Text())) > 0 {
+				sentences = append(sentences, scanner.Text())
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			fmt.Fprintf(stderr, "gocky parse: %v\n", err)
+			return exitFailure
+		}
+	}
+
+	status := exitOK
+	parser := gocky.NewParser(grammar)
+	keys := splitKeys(*startKeys)
+	for _, sentence := range sentences {
+		parses := matchingParses(parser, tokenize(sentence), keys)
+		if len(parses) == 0 {
+			fmt.Fprintf(stderr, "gocky parse: no parse for %q\n", sentence)
+			status = exitNoParse
+			continue
+		}
+		if err := format(stdout, sentence, parses); err != nil {
+			fmt.Fprintf(stderr, "gocky parse: %v\n", err)
+			return exitFailure
+		}
+	}
+	return status
+}
+
+// matchingParses parses the words, keeping only the parses from the keys when there are any
+func matchingParses(parser *gocky.Parser, words []string, keys []string) []gocky.Parse {
+	parses := parser.Parses(words)
+	if len(keys) == 0 {
+		return parses
+	}
+	matching := []gocky.Parse{}
+	for _, parse := range parses {
+		if containsKey(keys, parse.Key()) {
+			matching = append(matching, parse)
+		}
+	}
+	return matching
+}
+
+// tokenize splits a sentence into words with the default tokenizer
+func tokenize(sentence string) []string {
+	return tokenizer.Words(tokenizer.Tokenize(sentence))
+}
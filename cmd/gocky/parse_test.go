@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRunParse(t *testing.T) {
+	grammarPath := writeGrammarFile(t, "book.gky", bookFlightGrammar)
+
+	type test struct {
+		name           string
+		args           []string
+		stdin          string
+		expectedStatus int
+		expectedOutput string
+		expectedError  string
+	}
+
+	testCases := []test{
+		{
+			name:           "arguments",
+			args:           []string{"-grammar", grammarPath, "book that flight", "book a book"},
+			expectedStatus: exitOK,
+			expectedOutput: "(VP (V book) (NP (DT that) (N flight)))\n(VP (V book) (NP (DT a) (N book)))\n",
+		},
+		{
+			name:           "stdin",
+			args:           []string{"-grammar", grammarPath},
+			stdin:          "book the flight\n\n",
+			expectedStatus: exitOK,
+			expectedOutput: "(VP (V book) (NP (DT the) (N flight)))\n",
+		},
+		{
+			name:           "start keys",
+			args:           []string{"--grammar", grammarPath, "--start", "NP", "the flight", "book the flight"},
+			expectedStatus: exitNoParse,
+			expectedOutput: "(NP (DT the) (N flight))\n",
+			expectedError:  "no parse for \"book the flight\"",
+		},
+		{
+			name:           "no parse",
+			args:           []string{"-grammar", grammarPath, "flight that book"},
+			expectedStatus: exitNoParse,
+			expectedError:  "no parse",
+		},
+		{
+			name:           "missing grammar",
+			args:           []string{"book that flight"},
+			expectedStatus: exitUsage,
+			expectedError:  "-grammar is required",
+		},
+		{
+			name:           "unknown format",
+			args:           []string{"-grammar", grammarPath, "-format", "xml", "book that flight"},
+			expectedStatus: exitUsage,
+			expectedError:  "unknown format",
+		},
+		{
+			name:           "unreadable grammar",
+			args:           []string{"-grammar", grammarPath + ".missing", "book that flight"},
+			expectedStatus: exitFailure,
+			expectedError:  "no such file",
+		},
+	}
+
+	for _, testCase := range testCases {
+		stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+		status := runParse(testCase.args, strings.NewReader(testCase.stdin), stdout, stderr)
+		if status != testCase.expectedStatus {
+			t.Errorf("(Test \"%s\"), expected status %d, got %d (%s)", testCase.name, testCase.expectedStatus, status, stderr.String())
+		}
+		if stdout.String() != testCase.expectedOutput {
+			t.Errorf("(Test \"%s\"), expected output %q, got %q", testCase.name, testCase.expectedOutput, stdout.String())
+		}
+		if !strings.Contains(stderr.String(), testCase.expectedError) {
+			t.Errorf("(Test \"%s\"), expected error containing %q, got %q", testCase.name, testCase.expectedError, stderr.String())
+		}
+	}
+}
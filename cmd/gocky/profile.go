@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/kstafford3/gocky"
+)
+
+// runProfile parses a corpus and prints where the grammar's work and ambiguity come from, as gocky.Profile.Write describes
+// Each sentence is bounded by the same limits as gocky serve, and sentences that exceed them are reported as abandoned.
+func runProfile(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("profile", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	grammarPath := flags.String("grammar", "", "grammar file to profile")
+	corpusPath := flags.String("corpus", "-", "file of sentences to parse, one per line, \"-\" for standard input")
+	top := flags.Int("top", 10, "number of most ambiguous productions to list, all when zero")
+	timeout := flags.Duration("timeout", 10*time.Second, "longest time a single sentence may take, no limit when zero")
+	options := defaultParseLimits.defaults
+	flags.IntVar(&options.MaxWords, "max-words", options.MaxWords, "most words a sentence may have, no limit when zero")
+	flags.IntVar(&options.MaxCellSize, "max-cell-size", options.MaxCellSize, "largest chart cell a sentence may build, no limit when zero")
+	flags.IntVar(&options.MaxParseNodes, "max-parse-nodes", options.MaxParseNodes, "most chart parses a sentence may build, no limit when zero")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if len(*grammarPath) == 0 {
+		fmt.Fprintln(stderr, "gocky profile: -grammar is required")
+		return exitUsage
+	}
+	grammar, err := gocky.LoadGrammar(*grammarPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "gocky profile: %v\n", err)
+		return exitFailure
+	}
+	sentences, err := readCorpus(*corpusPath, stdin)
+	if err != nil {
+		fmt.Fprintf(stderr, "gocky profile: %v\n", err)
+		return exitFailure
+	}
+
+	profiler := gocky.NewProfiler(grammar)
+	for _, sentence := range sentences {
+		profileSentence(profiler, tokenize(sentence), options, *timeout)
+	}
+	if err := profiler.Profile().Write(stdout, *top); err != nil {
+		fmt.Fprintf(stderr, "gocky profile: %v\n", err)
+		return exitFailure
+	}
+	return exitOK
+}
+
+// profileSentence adds the words to the profile, giving up on them after the timeout unless it is zero
+// A sentence given up on is listed in the profile as abandoned, so its error needs no other handling.
+func profileSentence(profiler *gocky.Profiler, words []string, options gocky.ParseOptions, timeout time.Duration) {
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	profiler.AddContext(ctx, words, options)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRunProfile(t *testing.T) {
+	grammarPath := writeGrammarFile(t, "book.gky", bookFlightGrammar)
+	corpusPath := writeGrammarFile(t, "corpus.txt", "book that flight\nbook a book\n")
+
+	type test struct {
+		name           string
+		args           []string
+		stdin          string
+		expectedStatus int
+		expectedOutput string
+		expectedReport string
+		expectedError  string
+	}
+
+	testCases := []test{
+		{
+			name:           "corpus",
+			args:           []string{"-grammar", grammarPath, "-corpus", corpusPath},
+			expectedStatus: exitOK,
+			expectedOutput: "sentences: 2 (2 parsed, 0 ambiguous)\nparses: 1.00 average, 1 max for \"book that flight\"\n",
+		},
+		{
+			name:           "stdin",
+			args:           []string{"-grammar", grammarPath},
+			stdin:          "flight book\n",
+			expectedStatus: exitOK,
+			expectedOutput: "sentences: 1 (0 parsed, 0 ambiguous)\nparses: 0.00 average, 0 max\n",
+		},
+		{
+			name:           "limits",
+			args:           []string{"-grammar", grammarPath, "-corpus", corpusPath, "-max-words", "2", "-timeout", "1m"},
+			expectedStatus: exitOK,
+			expectedOutput: "sentences: 0 (0 parsed, 0 ambiguous)\n",
+			expectedReport: "abandoned: 2\n  \"book that flight\": gocky: parse exceeded",
+		},
+		{
+			name:           "unreadable corpus",
+			args:           []string{"-grammar", grammarPath, "-corpus", corpusPath + ".missing"},
+			expectedStatus: exitFailure,
+			expectedError:  "no such file",
+		},
+		{
+			name:           "missing grammar",
+			args:           []string{"-corpus", corpusPath},
+			expectedStatus: exitUsage,
+			expectedError:  "-grammar is required",
+		},
+	}
+
+	for _, testCase := range testCases {
+		stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+		status := runProfile(testCase.args, strings.NewReader(testCase.stdin), stdout, stderr)
+		if status != testCase.expectedStatus {
+			t.Errorf("(Test \"%s\"), expected status %d, got %d", testCase.name, testCase.expectedStatus, status)
+		}
+		if !strings.HasPrefix(stdout.String(), testCase.expectedOutput) {
+			t.Errorf("(Test \"%s\"), expected output starting with %q, got %q", testCase.name, testCase.expectedOutput, stdout.String())
+		}
+		if !strings.Contains(stdout.String(), testCase.expectedReport) {
+			t.Errorf("(Test \"%s\"), expected output containing %q, got %q", testCase.name, testCase.expectedReport, stdout.String())
+		}
+		if !strings.Contains(stderr.String(), testCase.expectedError) {
+			t.Errorf("(Test \"%s\"), expected error containing %q, got %q", testCase.name, testCase.expectedError, stderr.String())
+		}
+	}
+}
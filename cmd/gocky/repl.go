@@ -0,0 +1,407 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/kstafford3/gocky"
+)
+
+// replHelp describes the commands accepted by the REPL
+const replHelp = `Type a sentence to parse it, or one of these commands:
+  :add PRODUCTIONS     add productions, written as a line of a grammar file
+  :remove PRODUCTIONS  remove productions, written as a line of a grammar file
+  :grammar             show the grammar file, with its imports
+  :chart               show the chart for the last sentence
+  :start [KEYS]        set the comma separated keys a parse must start from, any key when empty
+  :save [PATH]         save the grammar file and its imports, to the file it was loaded from by default
+  :help                show this help
+  :quit                leave the REPL
+`
+
+// repl holds the state of an interactive session
+// The grammar holds the file's own productions, which are the ones edited and saved.
+// The productions of its imports are parsed with, but stay in their own files.
+type repl struct {
+	grammar     gocky.Grammar
+	imports     []gocky.GrammarImport
+	imported    gocky.Grammar
+	grammarPath string
+	startKeys   []string
+	chart       *gocky.Chart
+	stdout      io.Writer
+}
+
+// runREPL reads sentences and commands from stdin, parsing sentences against a grammar that can be edited as it goes
+// A grammar file that does not exist yet starts an empty grammar, which :save creates.
+func runREPL(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("repl", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	grammarPath := flags.String("grammar", "", "grammar file to load and save")
+	startKeys := flags.String("start", "", "comma separated keys a parse must start from, any key when empty")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if len(*grammarPath) == 0 {
+		fmt.Fprintln(stderr, "gocky repl: -grammar is required")
+		return exitUsage
+	}
+	session := &repl{grammarPath: *grammarPath, startKeys: splitKeys(*startKeys), stdout: stdout}
+	if err := session.load(); err != nil {
+		fmt.Fprintf(stderr, "gocky repl: %v\n", err)
+		return exitFailure
+	}
+	fmt.Fprintf(stdout, "%d productions loaded from %s, :help lists the commands\n", len(session.grammar)+len(session.imported), *grammarPath)
+	scanner := bufio.NewScanner(stdin)
+	for {
+		fmt.Fprint(stdout, "> ")
+		if !scanner.Scan() {
+			fmt.Fprintln(stdout)
+			break
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == ":quit" || line == ":q" {
+			break
+		}
+		if err := session.execute(line); err != nil {
+			fmt.Fprintf(stdout, "error: %v\n", err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintf(stderr, "gocky repl: %v\n", err)
+		return exitFailure
+	}
+	return exitOK
+}
+
+// load reads the grammar file's own productions and imports, and loads the imported productions
+// A grammar file that does not exist yet starts an empty grammar.
+func (r *repl) load() error {
+	file, err := os.Open(r.grammarPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		r.grammar = gocky.Grammar{}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	r.grammar, r.imports, err = gocky.ReadGrammarFile(file)
+	file.Close()
+	if err != nil || len(r.imports) == 0 {
+		return err
+	}
+	loaded, err := gocky.LoadGrammar(r.grammarPath)
+	if err != nil {
+		return err
+	}
+	// LoadGrammar puts the file's own productions before those it imports
+	r.imported = loaded[len(r.grammar):]
+	return nil
+}
+
+// parsingGrammar returns the grammar sentences are parsed with, the file's own productions followed by the imported ones
+func (r *repl) parsingGrammar() gocky.Grammar {
+	return append(append(gocky.Grammar{}, r.grammar...), r.imported...)
+}
+
+// execute runs a command, or parses a sentence
+func (r *repl) execute(line string) error {
+	if len(line) == 0 {
+		return nil
+	}
+	if !strings.HasPrefix(line, ":") {
+		return r.parse(line)
+	}
+	name, argument := line, ""
+	if space := strings.IndexAny(line, " \t"); space >= 0 {
+		name, argument = line[:space], strings.TrimSpace(line[space:])
+	}
+	switch name {
+	case ":add":
+		return r.add(argument)
+	case ":remove":
+		return r.remove(argument)
+	case ":grammar":
+		return gocky.WriteGrammarFile(r.stdout, r.imports, r.grammar)
+	case ":chart":
+		return r.writeChart()
+	case ":start":
+		r.startKeys = splitKeys(argument)
+		return nil
+	case ":save":
+		return r.save(argument)
+	case ":help":
+		_, err := io.WriteString(r.stdout, replHelp)
+		return err
+	}
+	return fmt.Errorf("unknown command %s, :help lists the commands", name)
+}
+
+// parse parses a sentence and prints its parses, keeping the chart for :chart
+func (r *repl) parse(sentence string) error {
+	r.chart = gocky.BuildChart(tokenize(sentence), r.parsingGrammar())
+	parses := []gocky.Parse{}
+	for _, parse := range r.chart.Parses() {
+		if len(r.startKeys) == 0 || containsKey(r.startKeys, parse.Key()) {
+			parses = append(parses, parse)
+		}
+	}
+	if len(parses) == 0 {
+		_, err := fmt.Fprintln(r.stdout, "no parse")
+		return err
+	}
+	if err := writeBracketed(r.stdout, sentence, parses); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(r.stdout, "%d parses\n", len(parses))
+	return err
+}
+
+// add appends the productions on a grammar line to the grammar
+func (r *repl) add(line string) error {
+	productions, err := gocky.ReadGrammar(strings.NewReader(line))
+	if err != nil {
+		return err
+	}
+	if len(productions) == 0 {
+		return fmt.Errorf(":add needs a production, such as NP -> DT N")
+	}
+	r.grammar = append(r.grammar, productions...)
+	_, err = fmt.Fprintf(r.stdout, "added %d productions\n", len(productions))
+	return err
+}
+
+// remove drops the productions on a grammar line from the grammar
+// Non-terminal productions are removed when their keys match, nominals are removed from the terminal productions of their key.
+// Imported productions belong to other files, so they cannot be removed.
+func (r *repl) remove(line string) error {
+	productions, err := gocky.ReadGrammar(strings.NewReader(line))
+	if err != nil {
+		return err
+	}
+	if len(productions) == 0 {
+		return fmt.Errorf(":remove needs a production, such as NP -> DT N")
+	}
+	grammar, removed := removeProductions(r.grammar, productions)
+	if removed == 0 {
+		if _, importedMatches := removeProductions(r.imported, productions); importedMatches > 0 {
+			return fmt.Errorf("the matching productions are imported, edit them in their own files")
+		}
+		return fmt.Errorf("no matching productions")
+	}
+	r.grammar = grammar
+	_, err = fmt.Fprintf(r.stdout, "removed %d productions\n", removed)
+	return err
+}
+
+// removeProductions returns the grammar without the removed productions, and the number of productions or nominals removed
+// Terminal productions left without nominals are dropped.
+// The probabilities left for a weighted key are scaled back up to sum to what the key's probabilities summed to before.
+func removeProductions(grammar gocky.Grammar, removed gocky.Grammar) (gocky.Grammar, int) {
+	remaining := gocky.Grammar{}
+	count := 0
+	for _, production := range grammar {
+		if len(production.Nominals()) == 0 {
+			if matchesNonterminal(production, removed) {
+				count++
+			} else {
+				remaining = append(remaining, production)
+			}
+			continue
+		}
+		nominals := []string{}
+		probabilities := []float64{}
+		for _, nominal := range production.Nominals() {
+			if matchesNominal(production.Key(), nominal, removed) {
+				count++
+				continue
+			}
+			nominals = append(nominals, nominal)
+			probabilities = append(probabilities, production.NominalProbability(nominal))
+		}
+		switch {
+		case len(nominals) == len(production.Nominals()):
+			remaining = append(remaining, production)
+		case len(nominals) == 0:
+		case production.Weighted():
+			remaining = append(remaining, gocky.WeightedTerminalProduction(production.Key(), nominals, probabilities))
+		default:
+			remaining = append(remaining, gocky.TerminalProduction(production.Key(), nominals))
+		}
+	}
+	if count == 0 {
+		return remaining, count
+	}
+	return renormalise(remaining, keyProbabilities(grammar)), count
+}
+
+// keyProbabilities sums the probabilities of each key whose productions are all weighted
+func keyProbabilities(grammar gocky.Grammar) map[string]float64 {
+	totals := map[string]float64{}
+	unweighted := map[string]bool{}
+	for _, production := range grammar {
+		if !production.Weighted() {
+			unweighted[production.Key()] = true
+			continue
+		}
+		if len(production.Nominals()) == 0 {
+			totals[production.Key()] += production.Probability()
+		}
+		for _, nominal := range production.Nominals() {
+			totals[production.Key()] += production.NominalProbability(nominal)
+		}
+	}
+	for key := range unweighted {
+		delete(totals, key)
+	}
+	return totals
+}
+
+// renormalise scales the probabilities of each weighted key so that they sum to its total in the targets
+// Keys whose probabilities already sum to their target, or to nothing, are left alone.
+func renormalise(grammar gocky.Grammar, targets map[string]float64) gocky.Grammar {
+	totals := keyProbabilities(grammar)
+	scaled := gocky.Grammar{}
+	for _, production := range grammar {
+		total, target := totals[production.Key()], targets[production.Key()]
+		if !production.Weighted() || total == 0 || total == target {
+			scaled = append(scaled, production)
+			continue
+		}
+		scale := target / total
+		if len(production.Nominals()) == 0 {
+			scaled = append(scaled, gocky.WeightedNonterminalProduction(production.Key(), production.Left(), production.Right(), production.Probability()*scale))
+			continue
+		}
+		probabilities := []float64{}
+		for _, nominal := range production.Nominals() {
+			probabilities = append(probabilities, production.NominalProbability(nominal)*scale)
+		}
+		scaled = append(scaled, gocky.WeightedTerminalProduction(production.Key(), production.Nominals(), probabilities))
+	}
+	return scaled
+}
+
+// matchesNonterminal reports whether a non-terminal production has the same keys as one of the removed productions
+func matchesNonterminal(production gocky.Production, removed gocky.Grammar) bool {
+	for _, candidate := range removed {
+		if len(candidate.Nominals()) == 0 && candidate.Key() == production.Key() && candidate.Left() == production.Left() && candidate.Right() == production.Right() {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesNominal reports whether one of the removed productions has the key and nominal
+func matchesNominal(key string, nominal string, removed gocky.Grammar) bool {
+	for _, candidate := range removed {
+		if candidate.Key() == key && containsKey(candidate.Nominals(), nominal) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeChart lists the keys in each non-empty cell of the last chart, shortest spans first
+func (r *repl) writeChart() error {
+	if r.chart == nil {
+		return fmt.Errorf("no sentence has been parsed yet")
+	}
+	words := r.chart.Words()
+	writer := tabwriter.NewWriter(r.stdout, 0, 4, 2, ' ', 0)
+	for spanLength := 1; spanLength <= len(words); spanLength++ {
+		for start := 0; start+spanLength <= len(words); start++ {
+			cell := r.chart.Cell(start, start+spanLength)
+			if len(cell) == 0 {
+				continue
+			}
+			keys := []string{}
+			for _, parse := range cell {
+				keys = append(keys, parse.Key())
+			}
+			fmt.Fprintf(writer, "%d-%d\t%s\t%s\n", start, start+spanLength, strings.Join(words[start:start+spanLength], " "), strings.Join(keys, " "))
+		}
+	}
+	return writer.Flush()
+}
+
+// save writes the grammar file to the path, or to the file it was loaded from
+// The import lines are kept rather than the imported productions, and relative import paths are adjusted for a file in another directory.
+func (r *repl) save(path string) error {
+	if len(path) == 0 {
+		path = r.grammarPath
+	}
+	imports, err := relocateImports(r.imports, r.grammarPath, path)
+	if err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := gocky.WriteGrammarFile(file, imports, r.grammar); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(r.stdout, "saved %d productions to %s\n", len(r.grammar), path)
+	return err
+}
+
+// relocateImports rewrites relative import paths of the grammar file at from, so that they find the same files from a grammar file at to
+func relocateImports(imports []gocky.GrammarImport, from string, to string) ([]gocky.GrammarImport, error) {
+	fromDirectory, toDirectory := filepath.Dir(from), filepath.Dir(to)
+	relocated := make([]gocky.GrammarImport, 0, len(imports))
+	for _, importLine := range imports {
+		if !filepath.IsAbs(importLine.Path) && fromDirectory != toDirectory {
+			importPath, err := filepath.Abs(filepath.Join(fromDirectory, importLine.Path))
+			if err != nil {
+				return nil, err
+			}
+			absoluteTo, err := filepath.Abs(toDirectory)
+			if err != nil {
+				return nil, err
+			}
+			if importLine.Path, err = filepath.Rel(absoluteTo, importPath); err != nil {
+				return nil, err
+			}
+			importLine.Path = filepath.ToSlash(importLine.Path)
+		}
+		relocated = append(relocated, importLine)
+	}
+	return relocated, nil
+}
+
+// splitKeys splits a comma separated list of keys, returning nil for an empty list
+func splitKeys(keys string) []string {
+	if len(strings.TrimSpace(keys)) == 0 {
+		return nil
+	}
+	split := []string{}
+	for _, key := range strings.Split(keys, ",") {
+		if key = strings.TrimSpace(key); len(key) > 0 {
+			split = append(split, key)
+		}
+	}
+	return split
+}
+
+// containsKey reports whether the keys include the key
+func containsKey(keys []string, key string) bool {
+	for _, candidate := range keys {
+		if candidate == key {
+			return true
+		}
+	}
+	return false
+}
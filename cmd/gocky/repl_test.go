@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kstafford3/gocky"
+)
+
+func TestRunREPL(t *testing.T) {
+	type test struct {
+		name             string
+		input            string
+		expectedOutput   []string
+		unexpectedOutput []string
+	}
+
+	testCases := []test{
+		{
+			name:           "parse",
+			input:          "book that flight\nflight that book\n",
+			expectedOutput: []string{"(VP (V book) (NP (DT that) (N flight)))\n1 parses\n", "no parse\n"},
+		},
+		{
+			name:           "add",
+			input:          ":add S -> NP VP\n:add DT -> \"this\"\nthe flight book this flight\n",
+			expectedOutput: []string{"added 1 productions", "(S (NP (DT the) (N flight)) (VP (V book) (NP (DT this) (N flight))))"},
+		},
+		{
+			name:             "remove",
+			input:            ":remove DT -> \"that\" | \"a\"\nbook that flight\n:remove VP -> V NP\nbook the flight\n:remove NP -> N N\n",
+			expectedOutput:   []string{"removed 2 productions", "removed 1 productions", "error: no matching productions"},
+			unexpectedOutput: []string{"(VP"},
+		},
+		{
+			name:           "start keys",
+			input:          ":start NP\nbook the flight\nthe flight\n",
+			expectedOutput: []string{"no parse\n", "(NP (DT the) (N flight))"},
+		},
+		{
+			name:  "chart",
+			input: ":chart\nbook that flight\n:chart\n",
+			expectedOutput: []string{
+				"error: no sentence has been parsed yet",
+				"0-1  book              N V\n" +
+					"1-2  that              DT\n" +
+					"2-3  flight            N\n" +
+					"1-3  that flight       NP\n" +
+					"0-3  book that flight  VP\n",
+			},
+		},
+		{
+			name:           "grammar",
+			input:          ":grammar\n",
+			expectedOutput: []string{"DT -> \"the\" | \"that\" | \"a\"\n", "VP -> V NP\n"},
+		},
+		{
+			name:           "errors",
+			input:          ":frobnicate\n:add NP -> DT\n:add\n",
+			expectedOutput: []string{"error: unknown command :frobnicate", "error: gocky: grammar line 1", "error: :add needs a production"},
+		},
+		{
+			name:             "quit",
+			input:            ":quit\nbook that flight\n",
+			unexpectedOutput: []string{"(VP"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		grammarPath := writeGrammarFile(t, "book.gky", bookFlightGrammar)
+		stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+		status := runREPL([]string{"-grammar", grammarPath}, strings.NewReader(testCase.input), stdout, stderr)
+		if status != exitOK {
+			t.Errorf("(Test \"%s\"), expected status %d, got %d (%s)", testCase.name, exitOK, status, stderr.String())
+		}
+		for _, expected := range testCase.expectedOutput {
+			if !strings.Contains(stdout.String(), expected) {
+				t.Errorf("(Test \"%s\"), expected output containing %q, got %q", testCase.name, expected, stdout.String())
+			}
+		}
+		for _, unexpected := range testCase.unexpectedOutput {
+			if strings.Contains(stdout.String(), unexpected) {
+				t.Errorf("(Test \"%s\"), expected output without %q, got %q", testCase.name, unexpected, stdout.String())
+			}
+		}
+	}
+}
+
+func TestRunREPLSave(t *testing.T) {
+	directory := t.TempDir()
+	grammarPath := filepath.Join(directory, "new.gky")
+	copyPath := filepath.Join(directory, "copy.gky")
+	input := ":add N -> \"dog\" [0.25] | \"cat\" [0.5]\n:add N -> N N [0.25]\n:save\n:remove N -> \"cat\"\n:save " + copyPath + "\n"
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	if status := runREPL([]string{"-grammar", grammarPath}, strings.NewReader(input), stdout, stderr); status != exitOK {
+		t.Fatalf("Expected status %d, got %d (%s)", exitOK, status, stderr.String())
+	}
+
+	type test struct {
+		path            string
+		expectedGrammar string
+	}
+
+	testCases := []test{
+		{path: grammarPath, expectedGrammar: "N -> \"dog\" [0.25] | \"cat\" [0.5]\nN -> N N [0.25]\n"},
+		{path: copyPath, expectedGrammar: "N -> \"dog\" [0.5]\nN -> N N [0.5]\n"},
+	}
+
+	for _, testCase := range testCases {
+		saved, err := os.ReadFile(testCase.path)
+		if err != nil {
+			t.Fatalf("(Test \"%s\"), unexpected error %v", testCase.path, err)
+		}
+		if string(saved) != testCase.expectedGrammar {
+			t.Errorf("(Test \"%s\"), expected grammar %q, got %q", testCase.path, testCase.expectedGrammar, string(saved))
+		}
+	}
+}
+
+func TestRunREPLSaveImports(t *testing.T) {
+	directory := t.TempDir()
+	grammarPath := filepath.Join(directory, "main.gky")
+	copyPath := filepath.Join(directory, "sub", "copy.gky")
+	if err := os.Mkdir(filepath.Dir(copyPath), 0o755); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	files := map[string]string{
+		grammarPath:                            "import \"common.gky\" as c\nS -> c.NUM N\nN -> \"dog\"\n",
+		filepath.Join(directory, "common.gky"): "NUM -> \"1\" | \"2\"\n",
+	}
+	for path, contents := range files {
+		if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+			t.Fatalf("Unexpected error %v", err)
+		}
+	}
+	input := "2 dog\n:add N -> \"cat\"\n:remove c.NUM -> \"1\"\n:grammar\n:save\n:save " + copyPath + "\n"
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	if status := runREPL([]string{"-grammar", grammarPath}, strings.NewReader(input), stdout, stderr); status != exitOK {
+		t.Fatalf("Expected status %d, got %d (%s)", exitOK, status, stderr.String())
+	}
+	expectedOutput := []string{
+		"3 productions loaded",
+		"(S (c.NUM 2) (N dog))",
+		"error: the matching productions are imported, edit them in their own files",
+		"import \"common.gky\" as c\nS -> c.NUM N\nN -> \"dog\"\nN -> \"cat\"\n",
+	}
+	for _, expected := range expectedOutput {
+		if !strings.Contains(stdout.String(), expected) {
+			t.Errorf("Expected output containing %q, got %q", expected, stdout.String())
+		}
+	}
+
+	type test struct {
+		path            string
+		expectedGrammar string
+	}
+
+	testCases := []test{
+		{path: grammarPath, expectedGrammar: "import \"common.gky\" as c\nS -> c.NUM N\nN -> \"dog\"\nN -> \"cat\"\n"},
+		{path: copyPath, expectedGrammar: "import \"../common.gky\" as c\nS -> c.NUM N\nN -> \"dog\"\nN -> \"cat\"\n"},
+	}
+
+	for _, testCase := range testCases {
+		saved, err := os.ReadFile(testCase.path)
+		if err != nil {
+			t.Fatalf("(Test \"%s\"), unexpected error %v", testCase.path, err)
+		}
+		if string(saved) != testCase.expectedGrammar {
+			t.Errorf("(Test \"%s\"), expected grammar %q, got %q", testCase.path, testCase.expectedGrammar, string(saved))
+		}
+		if _, err := gocky.LoadGrammar(testCase.path); err != nil {
+			t.Errorf("(Test \"%s\"), expected the saved grammar to load, got %v", testCase.path, err)
+		}
+	}
+}
+
+func TestRunREPLUsage(t *testing.T) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	if status := runREPL([]string{}, strings.NewReader(""), stdout, stderr); status != exitUsage {
+		t.Errorf("Expected status %d, got %d", exitUsage, status)
+	}
+	if !strings.Contains(stderr.String(), "-grammar is required") {
+		t.Errorf("Expected a usage error, got %q", stderr.String())
+	}
+}
@@ -0,0 +1,382 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kstafford3/gocky"
+)
+
+// grammarFlags collects repeated -grammar name=path flags
+type grammarFlags map[string]string
+
+func (g grammarFlags) String() string {
+	pairs := []string{}
+	for name, path := range g {
+		pairs = append(pairs, name+"="+path)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (g grammarFlags) Set(value string) error {
+	separator := strings.Index(value, "=")
+	if separator <= 0 || separator == len(value)-1 {
+		return fmt.Errorf("expected name=path, got %q", value)
+	}
+	name := value[:separator]
+	if _, ok := g[name]; ok {
+		return fmt.Errorf("grammar %q is loaded twice", name)
+	}
+	g[name] = value[separator+1:]
+	return nil
+}
+
+// runServe loads the named grammars and serves the HTTP API until interrupted
+// With -watch, changed grammar files are reloaded while the server runs, and a file that fails to load leaves the previous version in use.
+func runServe(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	address := flags.String("addr", "localhost:8080", "address to listen on")
+	timeout := flags.Duration("timeout", 10*time.Second, "longest time a single parse may take, no limit when zero")
+	watch := flags.Duration("watch", 0, "how often to check the grammar files for changes, never when zero")
+	limits := defaultParseLimits
+	flags.IntVar(&limits.maximums.MaxWords, "max-words", limits.maximums.MaxWords, "most words a parse request may ask for, no limit when zero")
+	flags.IntVar(&limits.maximums.MaxCellSize, "max-cell-size", limits.maximums.MaxCellSize, "largest chart cell a parse request may ask for, no limit when zero")
+	flags.IntVar(&limits.maximums.MaxParseNodes, "max-parse-nodes", limits.maximums.MaxParseNodes, "most chart parses a parse request may ask for, no limit when zero")
+	paths := grammarFlags{}
+	flags.Var(paths, "grammar", "grammar to serve as name=path, may be repeated")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if len(paths) == 0 {
+		fmt.Fprintln(stderr, "gocky serve: at least one -grammar name=path is required")
+		return exitUsage
+	}
+	registry := gocky.NewRegistry()
+	for name, path := range paths {
+		if err := registry.Load(name, path); err != nil {
+			fmt.Fprintf(stderr, "gocky serve: %v\n", err)
+			return exitFailure
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if *watch > 0 {
+		go registry.Watch(ctx, *watch, func(name string, err error) {
+			fmt.Fprintf(stderr, "gocky serve: keeping the previous version: %v\n", err)
+		})
+	}
+	server := &http.Server{Addr: *address, Handler: newServer(registry, *timeout, limits).handler()}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+	fmt.Fprintf(stdout, "serving %d grammars on http://%s\n", len(paths), *address)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Fprintf(stderr, "gocky serve: %v\n", err)
+		return exitFailure
+	}
+	return exitOK
+}
+
+// maxRequestBytes is the largest request body the server reads
+const maxRequestBytes = 1 << 20
+
+// parseLimits are the server's bounds on the work done by one parse request
+// A limit the request leaves at zero takes its default, and a request may not ask for more than the maximum.
+// A default that is zero or above the maximum is taken as the maximum, and a zero maximum leaves that limit to the request.
+type parseLimits struct {
+	defaults gocky.ParseOptions
+	maximums gocky.ParseOptions
+}
+
+// defaultParseLimits are the limits of gocky serve unless its flags change the maximums
+var defaultParseLimits = parseLimits{
+	defaults: gocky.ParseOptions{MaxWords: 50, MaxCellSize: 1000, MaxParseNodes: 100000},
+	maximums: gocky.ParseOptions{MaxWords: 200, MaxCellSize: 100000, MaxParseNodes: 10000000},
+}
+
+// options resolves the limits of a parse request against the server's limits
+func (l parseLimits) options(body parseRequest) (gocky.ParseOptions, error) {
+	options := gocky.ParseOptions{}
+	resolved := []struct {
+		name      string
+		requested int
+		limit     *int
+		def       int
+		maximum   int
+	}{
+		{"maxWords", body.MaxWords, &options.MaxWords, l.defaults.MaxWords, l.maximums.MaxWords},
+		{"maxCellSize", body.MaxCellSize, &options.MaxCellSize, l.defaults.MaxCellSize, l.maximums.MaxCellSize},
+		{"maxParseNodes", body.MaxParseNodes, &options.MaxParseNodes, l.defaults.MaxParseNodes, l.maximums.MaxParseNodes},
+	}
+	for _, limit := range resolved {
+		switch {
+		case limit.requested < 0:
+			return options, fmt.Errorf("%s must not be negative", limit.name)
+		case limit.maximum > 0 && limit.requested > limit.maximum:
+			return options, fmt.Errorf("%s must be at most %d", limit.name, limit.maximum)
+		case limit.requested > 0:
+			*limit.limit = limit.requested
+		case limit.maximum > 0 && (limit.def == 0 || limit.def > limit.maximum):
+			*limit.limit = limit.maximum
+		default:
+			*limit.limit = limit.def
+		}
+	}
+	return options, nil
+}
+
+// server answers parse requests against the grammars of a registry
+type server struct {
+	registry *gocky.Registry
+	timeout  time.Duration
+	limits   parseLimits
+}
+
+// newServer creates a server for the grammars of the registry
+// Each parse is abandoned after the timeout, unless the timeout is zero, and is bounded by the limits.
+func newServer(registry *gocky.Registry, timeout time.Duration, limits parseLimits) *server {
+	return &server{registry: registry, timeout: timeout, limits: limits}
+}
+
+// handler routes the API endpoints
+//
+//	GET  /health    reports that the server is up
+//	GET  /grammars  lists the grammars being served
+//	POST /reload    reloads the grammar files, keeping the previous version of any that fail to load
+//	POST /parse     parses a sentence, as described by parseRequest
+func (s *server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", s.handleHealth)
+	mux.HandleFunc("/grammars", s.handleGrammars)
+	mux.HandleFunc("/reload", s.handleReload)
+	mux.HandleFunc("/parse", s.handleParse)
+	return mux
+}
+
+// errorResponse is the body of every failed request
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// writeJSONResponse writes the value as the JSON body of the response
+func writeJSONResponse(writer http.ResponseWriter, status int, value interface{}) {
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(status)
+	json.NewEncoder(writer).Encode(value)
+}
+
+// writeError writes an errorResponse
+func writeError(writer http.ResponseWriter, status int, format string, args ...interface{}) {
+	writeJSONResponse(writer, status, errorResponse{Error: fmt.Sprintf(format, args...)})
+}
+
+// allowMethod reports whether the request uses the method, writing an error response when it does not
+func allowMethod(writer http.ResponseWriter, request *http.Request, method string) bool {
+	if request.Method == method {
+		return true
+	}
+	writer.Header().Set("Allow", method)
+	writeError(writer, http.StatusMethodNotAllowed, "%s requires %s", request.URL.Path, method)
+	return false
+}
+
+func (s *server) handleHealth(writer http.ResponseWriter, request *http.Request) {
+	if !allowMethod(writer, request, http.MethodGet) {
+		return
+	}
+	writeJSONResponse(writer, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// grammarSummary describes a grammar in the /grammars listing
+// Error holds the reason the most recent load failed, while the listed version stays in use.
+type grammarSummary struct {
+	Name        string   `json:"name"`
+	Version     int      `json:"version"`
+	Productions int      `json:"productions"`
+	Keys        []string `json:"keys"`
+	Error       string   `json:"error,omitempty"`
+}
+
+// grammarSummaries describes every grammar in the registry
+func (s *server) grammarSummaries() map[string][]grammarSummary {
+	summaries := []grammarSummary{}
+	for _, name := range s.registry.Names() {
+		summary := grammarSummary{Name: name, Keys: []string{}}
+		if registered, ok := s.registry.Grammar(name); ok {
+			summary.Version = registered.Version
+			summary.Productions = len(registered.Grammar)
+			summary.Keys = registered.Grammar.Keys()
+			sort.Strings(summary.Keys)
+		}
+		if err := s.registry.Err(name); err != nil {
+			summary.Error = err.Error()
+		}
+		summaries = append(summaries, summary)
+	}
+	return map[string][]grammarSummary{"grammars": summaries}
+}
+
+func (s *server) handleGrammars(writer http.ResponseWriter, request *http.Request) {
+	if !allowMethod(writer, request, http.MethodGet) {
+		return
+	}
+	writeJSONResponse(writer, http.StatusOK, s.grammarSummaries())
+}
+
+// handleReload reloads every grammar that came from a file, then lists the grammars
+// The response is 500 if any grammar failed to load, with the failures in the listing.
+func (s *server) handleReload(writer http.ResponseWriter, request *http.Request) {
+	if !allowMethod(writer, request, http.MethodPost) {
+		return
+	}
+	status := http.StatusOK
+	for _, name := range s.registry.Names() {
+		if registered, ok := s.registry.Grammar(name); ok && len(registered.Path) == 0 {
+			continue
+		}
+		if err := s.registry.Reload(name); err != nil {
+			status = http.StatusInternalServerError
+		}
+	}
+	writeJSONResponse(writer, status, s.grammarSummaries())
+}
+
+// parseRequest is the body of POST /parse
+// Either Words or Text holds the sentence, Text is split into words by the default tokenizer.
+// Grammar may be left out when the server only has one grammar.
+// K keeps only the K most probable parses, zero keeps them all.
+// The limits may tighten the server's limits, as parseLimits describes.
+type parseRequest struct {
+	Grammar       string   `json:"grammar"`
+	Words         []string `json:"words"`
+	Text          string   `json:"text"`
+	Start         []string `json:"start"`
+	K             int      `json:"k"`
+	MaxWords      int      `json:"maxWords"`
+	MaxCellSize   int      `json:"maxCellSize"`
+	MaxParseNodes int      `json:"maxParseNodes"`
+}
+
+// scoredTree is a parse in a parseResponse, with its probability
+type scoredTree struct {
+	Probability float64   `json:"probability"`
+	Tree        *jsonTree `json:"tree"`
+}
+
+// parseResponse is the body of a successful POST /parse
+// Count is the number of parses found before K was applied.
+type parseResponse struct {
+	Grammar string       `json:"grammar"`
+	Words   []string     `json:"words"`
+	Count   int          `json:"count"`
+	Parses  []scoredTree `json:"parses"`
+}
+
+func (s *server) handleParse(writer http.ResponseWriter, request *http.Request) {
+	if !allowMethod(writer, request, http.MethodPost) {
+		return
+	}
+	body := parseRequest{}
+	decoder := json.NewDecoder(http.MaxBytesReader(writer, request.Body, maxRequestBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&body); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(writer, http.StatusRequestEntityTooLarge, "request body is larger than %d bytes", tooLarge.Limit)
+			return
+		}
+		writeError(writer, http.StatusBadRequest, "invalid request: %v", err)
+		return
+	}
+	name := body.Grammar
+	if names := s.registry.Names(); len(name) == 0 && len(names) == 1 {
+		name = names[0]
+	}
+	grammar, ok := s.registry.Grammar(name)
+	if !ok {
+		writeError(writer, http.StatusNotFound, "unknown grammar %q", name)
+		return
+	}
+	words := body.Words
+	if len(words) > 0 && len(body.Text) > 0 {
+		writeError(writer, http.StatusBadRequest, "give either words or text, not both")
+		return
+	}
+	if len(words) == 0 {
+		words = tokenize(body.Text)
+	}
+	if len(words) == 0 {
+		writeError(writer, http.StatusBadRequest, "the sentence has no words")
+		return
+	}
+	if body.K < 0 {
+		writeError(writer, http.StatusBadRequest, "k must not be negative")
+		return
+	}
+	options, err := s.limits.options(body)
+	if err != nil {
+		writeError(writer, http.StatusBadRequest, "%v", err)
+		return
+	}
+
+	ctx := request.Context()
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+	parses, err := grammar.ParsesContext(ctx, words, options)
+	var limitErr *gocky.LimitError
+	switch {
+	case errors.As(err, &limitErr):
+		writeError(writer, http.StatusUnprocessableEntity, "%v", err)
+		return
+	case errors.Is(err, context.DeadlineExceeded):
+		writeError(writer, http.StatusServiceUnavailable, "parse timed out")
+		return
+	case errors.Is(err, context.Canceled):
+		// The client went away, or the server is shutting down, so there is no one to answer
+		return
+	case err != nil:
+		writeError(writer, http.StatusInternalServerError, "%v", err)
+		return
+	}
+
+	type scoredParse struct {
+		parse       *gocky.Parse
+		probability float64
+	}
+	matching := []scoredParse{}
+	for parseIndex := range parses {
+		if len(body.Start) == 0 || containsKey(body.Start, parses[parseIndex].Key()) {
+			matching = append(matching, scoredParse{parse: &parses[parseIndex], probability: parses[parseIndex].Probability()})
+		}
+	}
+	sort.SliceStable(matching, func(i, j int) bool {
+		return matching[i].probability > matching[j].probability
+	})
+	response := parseResponse{Grammar: name, Words: words, Count: len(matching), Parses: []scoredTree{}}
+	if body.K > 0 && len(matching) > body.K {
+		matching = matching[:body.K]
+	}
+	for _, scored := range matching {
+		response.Parses = append(response.Parses, scoredTree{Probability: scored.probability, Tree: newJSONTree(scored.parse)})
+	}
+	writeJSONResponse(writer, http.StatusOK, response)
+}
@@ -0,0 +1,384 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kstafford3/gocky"
+)
+
+// testServer serves the book flight grammar, and a weighted grammar with an ambiguous sentence
+func testServer(t *testing.T) *httptest.Server {
+	book, err := gocky.ReadGrammar(strings.NewReader(bookFlightGrammar))
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	weighted, err := gocky.ReadGrammar(strings.NewReader(`
+		N -> "fish" [0.6] | "people" [0.4]
+		V -> "fish" [1]
+		S -> N V [1]
+		N -> N N [0.2]
+		V -> V N [0.8]
+	`))
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	registry := gocky.NewRegistry()
+	registry.Set("book", book)
+	registry.Set("fish", weighted)
+	server := httptest.NewServer(newServer(registry, time.Second, defaultParseLimits).handler())
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestServeParse(t *testing.T) {
+	server := testServer(t)
+
+	type test struct {
+		name           string
+		body           string
+		expectedStatus int
+		expectedCount  int
+		expectedTrees  []string
+		expectedError  string
+	}
+
+	testCases := []test{
+		{
+			name:           "words",
+			body:           `{"grammar": "book", "words": ["book", "that", "flight"]}`,
+			expectedStatus: http.StatusOK,
+			expectedCount:  1,
+			expectedTrees:  []string{`{"key":"VP","start":0,"end":3,"children":[{"key":"V","start":0,"end":1,"word":"book"},{"key":"NP","start":1,"end":3,"children":[{"key":"DT","start":1,"end":2,"word":"that"},{"key":"N","start":2,"end":3,"word":"flight"}]}]}`},
+		},
+		{
+			name:           "text",
+			body:           `{"grammar": "book", "text": " the  flight "}`,
+			expectedStatus: http.StatusOK,
+			expectedCount:  1,
+			expectedTrees:  []string{`{"key":"NP","start":0,"end":2,"children":[{"key":"DT","start":0,"end":1,"word":"the"},{"key":"N","start":1,"end":2,"word":"flight"}]}`},
+		},
+		{
+			name:           "start keys",
+			body:           `{"grammar": "fish", "text": "people fish fish", "start": ["V"]}`,
+			expectedStatus: http.StatusOK,
+			expectedCount:  0,
+			expectedTrees:  []string{},
+		},
+		{
+			name:           "k best",
+			body:           `{"grammar": "fish", "text": "fish fish fish", "start": ["S"], "k": 1}`,
+			expectedStatus: http.StatusOK,
+			expectedCount:  2,
+			expectedTrees:  []string{`{"key":"S","start":0,"end":3,"children":[{"key":"N","start":0,"end":1,"word":"fish"},{"key":"V","start":1,"end":3,"children":[{"key":"V","start":1,"end":2,"word":"fish"},{"key":"N","start":2,"end":3,"word":"fish"}]}]}`},
+		},
+		{
+			name:           "limits",
+			body:           `{"grammar": "book", "text": "book that flight", "maxWords": 2}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedError:  "gocky: parse exceeded max words (2)",
+		},
+		{
+			name:           "limit above maximum",
+			body:           `{"grammar": "book", "text": "book that flight", "maxWords": 201}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "maxWords must be at most 200",
+		},
+		{
+			name:           "negative limit",
+			body:           `{"grammar": "book", "text": "book that flight", "maxParseNodes": -1}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "maxParseNodes must not be negative",
+		},
+		{
+			name:           "body too large",
+			body:           `{"grammar": "book", "text": "` + strings.Repeat("book ", maxRequestBytes/5) + `"}`,
+			expectedStatus: http.StatusRequestEntityTooLarge,
+			expectedError:  "request body is larger than 1048576 bytes",
+		},
+		{
+			name:           "unknown grammar",
+			body:           `{"grammar": "klingon", "text": "book that flight"}`,
+			expectedStatus: http.StatusNotFound,
+			expectedError:  `unknown grammar "klingon"`,
+		},
+		{
+			name:           "missing grammar",
+			body:           `{"text": "book that flight"}`,
+			expectedStatus: http.StatusNotFound,
+			expectedError:  `unknown grammar ""`,
+		},
+		{
+			name:           "no words",
+			body:           `{"grammar": "book", "text": "  "}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "the sentence has no words",
+		},
+		{
+			name:           "words and text",
+			body:           `{"grammar": "book", "words": ["book"], "text": "book"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "give either words or text, not both",
+		},
+		{
+			name:           "negative k",
+			body:           `{"grammar": "book", "text": "book", "k": -1}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "k must not be negative",
+		},
+		{
+			name:           "unknown field",
+			body:           `{"grammar": "book", "sentence": "book"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  `invalid request: json: unknown field "sentence"`,
+		},
+	}
+
+	for _, testCase := range testCases {
+		response, err := http.Post(server.URL+"/parse", "application/json", strings.NewReader(testCase.body))
+		if err != nil {
+			t.Fatalf("(Test \"%s\"), unexpected error %v", testCase.name, err)
+		}
+		body := struct {
+			Count  int `json:"count"`
+			Parses []struct {
+				Probability float64         `json:"probability"`
+				Tree        json.RawMessage `json:"tree"`
+			} `json:"parses"`
+			Error string `json:"error"`
+		}{}
+		err = json.NewDecoder(response.Body).Decode(&body)
+		response.Body.Close()
+		if err != nil {
+			t.Fatalf("(Test \"%s\"), unexpected error %v", testCase.name, err)
+		}
+		if response.StatusCode != testCase.expectedStatus {
+			t.Errorf("(Test \"%s\"), expected status %d, got %d (%s)", testCase.name, testCase.expectedStatus, response.StatusCode, body.Error)
+		}
+		if body.Error != testCase.expectedError {
+			t.Errorf("(Test \"%s\"), expected error %q, got %q", testCase.name, testCase.expectedError, body.Error)
+		}
+		if testCase.expectedStatus != http.StatusOK {
+			continue
+		}
+		trees := []string{}
+		for _, parse := range body.Parses {
+			trees = append(trees, string(parse.Tree))
+		}
+		if body.Count != testCase.expectedCount || !reflect.DeepEqual(trees, testCase.expectedTrees) {
+			t.Errorf("(Test \"%s\"), expected %d parses %v, got %d parses %v", testCase.name, testCase.expectedCount, testCase.expectedTrees, body.Count, trees)
+		}
+	}
+}
+
+func TestParseLimitsOptions(t *testing.T) {
+	limits := parseLimits{
+		defaults: gocky.ParseOptions{MaxWords: 10, MaxCellSize: 500},
+		maximums: gocky.ParseOptions{MaxWords: 20, MaxCellSize: 100},
+	}
+
+	type test struct {
+		name            string
+		body            parseRequest
+		expectedOptions gocky.ParseOptions
+		expectedError   string
+	}
+
+	testCases := []test{
+		{name: "defaults", body: parseRequest{}, expectedOptions: gocky.ParseOptions{MaxWords: 10, MaxCellSize: 100}},
+		{name: "tighter", body: parseRequest{MaxWords: 5, MaxCellSize: 50, MaxParseNodes: 7}, expectedOptions: gocky.ParseOptions{MaxWords: 5, MaxCellSize: 50, MaxParseNodes: 7}},
+		{name: "maximum", body: parseRequest{MaxWords: 20}, expectedOptions: gocky.ParseOptions{MaxWords: 20, MaxCellSize: 100}},
+		{name: "above maximum", body: parseRequest{MaxCellSize: 101}, expectedError: "maxCellSize must be at most 100"},
+		{name: "negative", body: parseRequest{MaxWords: -1}, expectedError: "maxWords must not be negative"},
+	}
+
+	for _, testCase := range testCases {
+		options, err := limits.options(testCase.body)
+		if len(testCase.expectedError) > 0 {
+			if err == nil || err.Error() != testCase.expectedError {
+				t.Errorf("(Test \"%s\"), expected error %q, got %v", testCase.name, testCase.expectedError, err)
+			}
+			continue
+		}
+		if err != nil || options != testCase.expectedOptions {
+			t.Errorf("(Test \"%s\"), expected %+v, got %+v %v", testCase.name, testCase.expectedOptions, options, err)
+		}
+	}
+}
+
+func TestServeParseCancelled(t *testing.T) {
+	registry := gocky.NewRegistry()
+	registry.Load("book", writeGrammarFile(t, "book.gky", bookFlightGrammar))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	request := httptest.NewRequest(http.MethodPost, "/parse", strings.NewReader(`{"text": "book that flight"}`)).WithContext(ctx)
+	recorder := httptest.NewRecorder()
+	newServer(registry, time.Second, defaultParseLimits).handler().ServeHTTP(recorder, request)
+	if recorder.Body.Len() != 0 {
+		t.Errorf("Expected no response for a client that went away, got %d %q", recorder.Code, recorder.Body.String())
+	}
+}
+
+func TestServeOneGrammar(t *testing.T) {
+	registry := gocky.NewRegistry()
+	registry.Load("book", writeGrammarFile(t, "book.gky", bookFlightGrammar))
+	server := httptest.NewServer(newServer(registry, 0, defaultParseLimits).handler())
+	defer server.Close()
+
+	response, err := http.Post(server.URL+"/parse", "application/json", strings.NewReader(`{"text": "book that flight"}`))
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	defer response.Body.Close()
+	body := parseResponse{}
+	if err := json.NewDecoder(response.Body).Decode(&body); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if response.StatusCode != http.StatusOK || body.Grammar != "book" || body.Count != 1 {
+		t.Errorf("Expected the only grammar to be used, got status %d and %+v", response.StatusCode, body)
+	}
+}
+
+func TestServeEndpoints(t *testing.T) {
+	server := testServer(t)
+
+	type test struct {
+		name           string
+		method         string
+		path           string
+		expectedStatus int
+		expectedBody   string
+	}
+
+	testCases := []test{
+		{
+			name:           "health",
+			method:         http.MethodGet,
+			path:           "/health",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"status":"ok"}`,
+		},
+		{
+			name:           "grammars",
+			method:         http.MethodGet,
+			path:           "/grammars",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"grammars":[{"name":"book","version":1,"productions":5,"keys":["DT","N","NP","V","VP"]},{"name":"fish","version":1,"productions":5,"keys":["N","S","V"]}]}`,
+		},
+		{
+			name:           "parse with get",
+			method:         http.MethodGet,
+			path:           "/parse",
+			expectedStatus: http.StatusMethodNotAllowed,
+			expectedBody:   `{"error":"/parse requires POST"}`,
+		},
+		{
+			name:           "grammars with post",
+			method:         http.MethodPost,
+			path:           "/grammars",
+			expectedStatus: http.StatusMethodNotAllowed,
+			expectedBody:   `{"error":"/grammars requires GET"}`,
+		},
+	}
+
+	for _, testCase := range testCases {
+		request, err := http.NewRequest(testCase.method, server.URL+testCase.path, nil)
+		if err != nil {
+			t.Fatalf("(Test \"%s\"), unexpected error %v", testCase.name, err)
+		}
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatalf("(Test \"%s\"), unexpected error %v", testCase.name, err)
+		}
+		body, err := io.ReadAll(response.Body)
+		response.Body.Close()
+		if err != nil {
+			t.Fatalf("(Test \"%s\"), unexpected error %v", testCase.name, err)
+		}
+		if response.StatusCode != testCase.expectedStatus {
+			t.Errorf("(Test \"%s\"), expected status %d, got %d", testCase.name, testCase.expectedStatus, response.StatusCode)
+		}
+		if strings.TrimSpace(string(body)) != testCase.expectedBody {
+			t.Errorf("(Test \"%s\"), expected body %s, got %s", testCase.name, testCase.expectedBody, string(body))
+		}
+	}
+}
+
+func TestGrammarFlags(t *testing.T) {
+	paths := grammarFlags{}
+	for _, value := range []string{"book=book.gky", "fish=a=b.gky"} {
+		if err := paths.Set(value); err != nil {
+			t.Errorf("(Test \"%s\"), unexpected error %v", value, err)
+		}
+	}
+	for _, value := range []string{"book=other.gky", "=book.gky", "book=", "book"} {
+		if err := paths.Set(value); err == nil {
+			t.Errorf("(Test \"%s\"), expected an error", value)
+		}
+	}
+	if paths.String() != "book=book.gky,fish=a=b.gky" {
+		t.Errorf("Expected book=book.gky,fish=a=b.gky, got %s", paths.String())
+	}
+}
+
+func TestServeReload(t *testing.T) {
+	path := writeGrammarFile(t, "dog.gky", "N -> \"dog\"\n")
+	registry := gocky.NewRegistry()
+	if err := registry.Load("dog", path); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	registry.Set("fish", gocky.Grammar{gocky.TerminalProduction("N", []string{"fish"})})
+	server := httptest.NewServer(newServer(registry, 0, defaultParseLimits).handler())
+	defer server.Close()
+
+	type test struct {
+		name           string
+		grammar        string
+		expectedStatus int
+		expectedBody   string
+	}
+
+	testCases := []test{
+		{
+			name:           "changed",
+			grammar:        "N -> \"dog\" | \"cat\"\nNP -> N N\n",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"grammars":[{"name":"dog","version":2,"productions":2,"keys":["N","NP"]},{"name":"fish","version":1,"productions":1,"keys":["N"]}]}`,
+		},
+		{
+			name:           "invalid",
+			grammar:        "NP -> N N\n",
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"grammars":[{"name":"dog","version":2,"productions":2,"keys":["N","NP"],"error":"gocky: loading grammar \"dog\": gocky: invalid grammar: production 0 (NP) uses \"N\", which no production produces"},{"name":"fish","version":1,"productions":1,"keys":["N"]}]}`,
+		},
+	}
+
+	for _, testCase := range testCases {
+		if err := os.WriteFile(path, []byte(testCase.grammar), 0o644); err != nil {
+			t.Fatalf("Unexpected error %v", err)
+		}
+		response, err := http.Post(server.URL+"/reload", "application/json", nil)
+		if err != nil {
+			t.Fatalf("(Test \"%s\"), unexpected error %v", testCase.name, err)
+		}
+		body, err := io.ReadAll(response.Body)
+		response.Body.Close()
+		if err != nil {
+			t.Fatalf("(Test \"%s\"), unexpected error %v", testCase.name, err)
+		}
+		if response.StatusCode != testCase.expectedStatus {
+			t.Errorf("(Test \"%s\"), expected status %d, got %d", testCase.name, testCase.expectedStatus, response.StatusCode)
+		}
+		if strings.TrimSpace(string(body)) != testCase.expectedBody {
+			t.Errorf("(Test \"%s\"), expected body %s, got %s", testCase.name, testCase.expectedBody, string(body))
+		}
+	}
+}
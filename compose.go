@@ -0,0 +1,103 @@
+package gocky
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NamespaceSeparator joins a namespace to the keys of a grammar, as in "dates.DAY"
+const NamespaceSeparator = "."
+
+// Namespace returns a copy of the grammar with every key prefixed by the name and NamespaceSeparator
+// Component keys are prefixed too, so a namespaced module only refers to its own keys.
+// Other grammars refer to the module's keys by their prefixed names, such as "dates.DAY".
+func (g Grammar) Namespace(name string) Grammar {
+	prefix := name + NamespaceSeparator
+	namespaced := make(Grammar, len(g))
+	for productionIndex, production := range g {
+		production.key = prefix + production.key
+		if len(production.left) > 0 {
+			production.left = prefix + production.left
+		}
+		if len(production.right) > 0 {
+			production.right = prefix + production.right
+		}
+		namespaced[productionIndex] = production
+	}
+	return namespaced
+}
+
+// Keys returns every key the grammar produces, in the order they first appear
+func (g Grammar) Keys() []string {
+	keys := []string{}
+	seen := map[string]bool{}
+	for _, production := range g {
+		if !seen[production.key] {
+			seen[production.key] = true
+			keys = append(keys, production.key)
+		}
+	}
+	return keys
+}
+
+// MergeConflict is a key produced by more than one of the grammars passed to Merge
+// Grammars holds the positions of those grammars in the arguments to Merge.
+type MergeConflict struct {
+	Key      string
+	Grammars []int
+}
+
+// MergeError is returned by Merge when grammars produce the same keys
+type MergeError struct {
+	Conflicts []MergeConflict
+}
+
+func (e *MergeError) Error() string {
+	conflicts := []string{}
+	for _, conflict := range e.Conflicts {
+		positions := []string{}
+		for _, position := range conflict.Grammars {
+			positions = append(positions, fmt.Sprint(position))
+		}
+		conflicts = append(conflicts, fmt.Sprintf("%q is produced by grammars %s", conflict.Key, strings.Join(positions, ", ")))
+	}
+	return "gocky: merge conflicts: " + strings.Join(conflicts, "; ")
+}
+
+// Merge combines grammars into one, keeping the productions of each grammar in order
+//
+// A key produced by more than one grammar is usually a collision between modules, so it is reported with a *MergeError.
+// The merged grammar is returned alongside the error, for callers that meant the grammars to share the key.
+// Namespace the grammars first to keep their keys apart.
+func Merge(grammars ...Grammar) (Grammar, error) {
+	merged := Grammar{}
+	for _, grammar := range grammars {
+		merged = append(merged, grammar...)
+	}
+	conflicts := mergeConflicts(grammars)
+	if len(conflicts) > 0 {
+		return merged, &MergeError{Conflicts: conflicts}
+	}
+	return merged, nil
+}
+
+// mergeConflicts finds the keys produced by more than one of the grammars, in the order they first appear
+func mergeConflicts(grammars []Grammar) []MergeConflict {
+	producers := map[string][]int{}
+	keys := []string{}
+	for grammarIndex, grammar := range grammars {
+		for _, key := range grammar.Keys() {
+			if _, ok := producers[key]; !ok {
+				keys = append(keys, key)
+			}
+			producers[key] = append(producers[key], grammarIndex)
+		}
+	}
+	conflicts := []MergeConflict{}
+	for _, key := range keys {
+		if len(producers[key]) > 1 {
+			conflicts = append(conflicts, MergeConflict{Key: key, Grammars: producers[key]})
+		}
+	}
+	return conflicts
+}
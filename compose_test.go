@@ -0,0 +1,84 @@
+package gocky
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestNamespace(t *testing.T) {
+	namespaced := bookFlight().Namespace("flights")
+	expected := Grammar{
+		TerminalProduction("flights.DT", []string{"the", "that", "a"}),
+		TerminalProduction("flights.N", []string{"book", "flight"}),
+		TerminalProduction("flights.V", []string{"book"}),
+		TerminalProduction("flights.JJ", []string{}),
+		NonterminalProduction("flights.NP", "flights.DT", "flights.N"),
+		NonterminalProduction("flights.VP", "flights.V", "flights.NP"),
+	}
+	compareGrammars(t, "namespace", expected, namespaced)
+
+	if original := bookFlight(); original[4].key != "NP" {
+		t.Errorf("Expected the original grammar to be unchanged, got %s", original[4].key)
+	}
+	parses := MatchingParses([]string{"book", "that", "flight"}, namespaced, []string{"flights.VP"})
+	if len(parses) != 1 {
+		t.Errorf("Expected 1 parse from the namespaced grammar, got %d", len(parses))
+	}
+}
+
+func TestKeys(t *testing.T) {
+	expectedKeys := []string{"N", "DT", "J"}
+	if keys := bigDog().Keys(); !reflect.DeepEqual(keys, expectedKeys) {
+		t.Errorf("Expected keys %v, got %v", expectedKeys, keys)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	type test struct {
+		name              string
+		grammars          []Grammar
+		expectedLength    int
+		expectedConflicts []MergeConflict
+	}
+
+	testCases := []test{
+		{name: "none", grammars: []Grammar{}, expectedLength: 0},
+		{name: "one", grammars: []Grammar{panda()}, expectedLength: len(panda())},
+		{
+			name:           "namespaced",
+			grammars:       []Grammar{bookFlight().Namespace("a"), bookFlight().Namespace("b")},
+			expectedLength: 2 * len(bookFlight()),
+		},
+		{
+			name:           "collisions",
+			grammars:       []Grammar{bookFlight(), panda(), Grammar{TerminalProduction("N", []string{"panda"})}},
+			expectedLength: len(bookFlight()) + len(panda()) + 1,
+			expectedConflicts: []MergeConflict{
+				{Key: "DT", Grammars: []int{0, 1}},
+				{Key: "N", Grammars: []int{0, 1, 2}},
+				{Key: "V", Grammars: []int{0, 1}},
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		merged, err := Merge(testCase.grammars...)
+		if len(merged) != testCase.expectedLength {
+			t.Errorf("(Test \"%s\"), expected %d productions, got %d", testCase.name, testCase.expectedLength, len(merged))
+		}
+		if len(testCase.expectedConflicts) == 0 {
+			if err != nil {
+				t.Errorf("(Test \"%s\"), unexpected error %v", testCase.name, err)
+			}
+			continue
+		}
+		var mergeErr *MergeError
+		if !errors.As(err, &mergeErr) {
+			t.Fatalf("(Test \"%s\"), expected a MergeError, got %v", testCase.name, err)
+		}
+		if !reflect.DeepEqual(mergeErr.Conflicts, testCase.expectedConflicts) {
+			t.Errorf("(Test \"%s\"), expected conflicts %v, got %v", testCase.name, testCase.expectedConflicts, mergeErr.Conflicts)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package gocky
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// HeadDirection names which component of a non-terminal production is its head
+type HeadDirection int
+
+const (
+	// HeadLeft makes the left component the head
+	HeadLeft HeadDirection = iota
+	// HeadRight makes the right component the head
+	HeadRight
+)
+
+// HeadRules chooses the head component for each production key
+// Keys missing from Directions use Default.
+type HeadRules struct {
+	Directions map[string]HeadDirection
+	Default    HeadDirection
+}
+
+// direction returns the head direction for a key
+func (r HeadRules) direction(key string) HeadDirection {
+	if direction, ok := r.Directions[key]; ok {
+		return direction
+	}
+	return r.Default
+}
+
+// Dependency is an arc from a head word to a dependent word
+// Words are numbered from 1, and the head of the whole sentence depends on the head 0 with the label "root".
+// A multi-word nominal, such as "New York", counts as a single word.
+// Every other arc is labelled with the key of the node where the dependent's phrase joins the head's phrase.
+type Dependency struct {
+	Head      int
+	Dependent int
+	Label     string
+}
+
+// Dependencies converts the parse into head-dependent arcs, one for each word, in word order
+// The head word of a terminal node is its own word, and the head word of a non-terminal node is the head word of its head component.
+// The head word of the other component depends on it.
+func (p *Parse) Dependencies(rules HeadRules) []Dependency {
+	leafPositions := map[*Parse]int{}
+	for leafIndex, leaf := range p.Leaves() {
+		leafPositions[leaf.Node] = leafIndex + 1
+	}
+	dependencies := []Dependency{}
+	heads := map[*Parse]int{}
+	WalkPostOrder(p, func(node *Parse, depth int) WalkAction {
+		if node.left == nil || node.right == nil {
+			heads[node] = leafPositions[node]
+			return WalkContinue
+		}
+		head, dependent := node.left, node.right
+		if rules.direction(node.production.key) == HeadRight {
+			head, dependent = node.right, node.left
+		}
+		heads[node] = heads[head]
+		dependencies = append(dependencies, Dependency{Head: heads[head], Dependent: heads[dependent], Label: node.production.key})
+		return WalkContinue
+	})
+	dependencies = append(dependencies, Dependency{Head: 0, Dependent: heads[p], Label: "root"})
+	sort.Slice(dependencies, func(i, j int) bool {
+		return dependencies[i].Dependent < dependencies[j].Dependent
+	})
+	return dependencies
+}
+
+// WriteCoNLLU writes the words of a parse and their dependencies as one sentence in CoNLL-U format
+// https://universaldependencies.org/format.html
+// The key of each word's terminal production is written as its language-specific part of speech, and unknown fields are written as "_".
+func WriteCoNLLU(writer io.Writer, parse *Parse, dependencies []Dependency) error {
+	heads := map[int]Dependency{}
+	for _, dependency := range dependencies {
+		heads[dependency.Dependent] = dependency
+	}
+	for leafIndex, leaf := range parse.Leaves() {
+		position := leafIndex + 1
+		head, label := "_", "_"
+		if dependency, ok := heads[position]; ok {
+			head, label = fmt.Sprint(dependency.Head), dependency.Label
+		}
+		_, err := fmt.Fprintf(writer, "%d\t%s\t_\t_\t%s\t_\t%s\t%s\t_\t_\n", position, leaf.Node.terminal, leaf.Node.production.key, head, label)
+		if err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintln(writer)
+	return err
+}
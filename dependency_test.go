@@ -0,0 +1,79 @@
+package gocky
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestDependencies(t *testing.T) {
+	type test struct {
+		name                 string
+		rules                HeadRules
+		expectedDependencies []Dependency
+	}
+
+	// (S3 (DN0 the panda) (VP2 eats (NP0 shoots (CCN and leaves))))
+	testCases := []test{
+		{
+			name:  "verb and noun heads",
+			rules: HeadRules{Directions: map[string]HeadDirection{"S3": HeadRight, "DN0": HeadRight, "CCN": HeadRight}},
+			expectedDependencies: []Dependency{
+				{Head: 2, Dependent: 1, Label: "DN0"},
+				{Head: 3, Dependent: 2, Label: "S3"},
+				{Head: 0, Dependent: 3, Label: "root"},
+				{Head: 3, Dependent: 4, Label: "VP2"},
+				{Head: 6, Dependent: 5, Label: "CCN"},
+				{Head: 4, Dependent: 6, Label: "NP0"},
+			},
+		},
+		{
+			name:  "all left",
+			rules: HeadRules{},
+			expectedDependencies: []Dependency{
+				{Head: 0, Dependent: 1, Label: "root"},
+				{Head: 1, Dependent: 2, Label: "DN0"},
+				{Head: 1, Dependent: 3, Label: "S3"},
+				{Head: 3, Dependent: 4, Label: "VP2"},
+				{Head: 4, Dependent: 5, Label: "NP0"},
+				{Head: 5, Dependent: 6, Label: "CCN"},
+			},
+		},
+	}
+
+	parse := &Parses([]string{"the", "panda", "eats", "shoots", "and", "leaves"}, panda())[0]
+	for _, testCase := range testCases {
+		actualDependencies := parse.Dependencies(testCase.rules)
+		if !reflect.DeepEqual(testCase.expectedDependencies, actualDependencies) {
+			t.Errorf("(Test \"%s\"), expected dependencies %v, got %v", testCase.name, testCase.expectedDependencies, actualDependencies)
+		}
+	}
+}
+
+func TestDependenciesMultiWordNominals(t *testing.T) {
+	parse := &Parses([]string{"look", "up", "New", "York"}, lookUpNewYork())[2]
+	expectedDependencies := []Dependency{
+		{Head: 0, Dependent: 1, Label: "root"},
+		{Head: 1, Dependent: 2, Label: "VP"},
+	}
+	if actualDependencies := parse.Dependencies(HeadRules{}); !reflect.DeepEqual(expectedDependencies, actualDependencies) {
+		t.Errorf("Expected dependencies %v, got %v", expectedDependencies, actualDependencies)
+	}
+}
+
+func TestWriteCoNLLU(t *testing.T) {
+	parse := &Parses([]string{"book", "that", "flight"}, bookFlight())[0]
+	dependencies := parse.Dependencies(HeadRules{Directions: map[string]HeadDirection{"NP": HeadRight}})
+
+	output := &bytes.Buffer{}
+	if err := WriteCoNLLU(output, parse, dependencies); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	expectedOutput := "1\tbook\t_\t_\tV\t_\t0\troot\t_\t_\n" +
+		"2\tthat\t_\t_\tDT\t_\t3\tNP\t_\t_\n" +
+		"3\tflight\t_\t_\tN\t_\t1\tVP\t_\t_\n" +
+		"\n"
+	if output.String() != expectedOutput {
+		t.Errorf("Expected output %q, got %q", expectedOutput, output.String())
+	}
+}
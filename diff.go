@@ -0,0 +1,160 @@
+package gocky
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// GrammarDiff lists the differences between two grammars, as found by Diff
+// Each list is sorted by key, with non-terminal productions before nominals.
+type GrammarDiff struct {
+	// Added holds the productions and nominals only in the new grammar
+	Added []DiffEntry
+	// Removed holds the productions and nominals only in the old grammar
+	Removed []DiffEntry
+	// Changed holds the productions and nominals in both grammars with different probabilities
+	Changed []DiffEntry
+}
+
+// DiffEntry is a non-terminal production, or a single nominal of a terminal production, that differs between two grammars
+// Non-terminal entries have Left and Right keys, and terminal entries have a Nominal.
+type DiffEntry struct {
+	Key            string
+	Left           string
+	Right          string
+	Nominal        string
+	OldProbability float64
+	NewProbability float64
+}
+
+// Diff compares two grammars, ignoring the order of their productions
+//
+// Non-terminal productions are matched by their keys, and terminal productions are compared nominal by nominal,
+// so moving a nominal from one production to another production with the same key is not a difference.
+// Probabilities are compared as Production.Probability and Production.NominalProbability report them,
+// so an unweighted production matches a weighted one with a probability of 1.
+// A production or nominal repeated within one grammar is compared once, and features and semantics are not compared.
+func Diff(oldGrammar Grammar, newGrammar Grammar) GrammarDiff {
+	oldProbabilities, oldOrder := diffProbabilities(oldGrammar)
+	newProbabilities, newOrder := diffProbabilities(newGrammar)
+	diff := GrammarDiff{Added: []DiffEntry{}, Removed: []DiffEntry{}, Changed: []DiffEntry{}}
+	for _, identity := range oldOrder {
+		oldProbability := oldProbabilities[identity]
+		newProbability, ok := newProbabilities[identity]
+		switch {
+		case !ok:
+			diff.Removed = append(diff.Removed, identity.entry(oldProbability, 0))
+		case oldProbability != newProbability:
+			diff.Changed = append(diff.Changed, identity.entry(oldProbability, newProbability))
+		}
+	}
+	for _, identity := range newOrder {
+		if _, ok := oldProbabilities[identity]; !ok {
+			diff.Added = append(diff.Added, identity.entry(0, newProbabilities[identity]))
+		}
+	}
+	for _, entries := range [][]DiffEntry{diff.Added, diff.Removed, diff.Changed} {
+		sortDiffEntries(entries)
+	}
+	return diff
+}
+
+// Empty reports whether the grammars had no differences
+func (d GrammarDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// Write writes the differences one per line, in the format described by ReadGrammar
+// Removed entries start with "-", added entries with "+", and changed entries with "~" followed by their old and new probabilities,
+// as in `~ NP -> DT N [0.5 -> 0.75]`.
+func (d GrammarDiff) Write(writer io.Writer) error {
+	for _, entry := range d.Removed {
+		if _, err := fmt.Fprintf(writer, "- %s\n", entry); err != nil {
+			return err
+		}
+	}
+	for _, entry := range d.Added {
+		if _, err := fmt.Fprintf(writer, "+ %s\n", entry); err != nil {
+			return err
+		}
+	}
+	for _, entry := range d.Changed {
+		oldProbability := strconv.FormatFloat(entry.OldProbability, 'g', -1, 64)
+		newProbability := strconv.FormatFloat(entry.NewProbability, 'g', -1, 64)
+		if _, err := fmt.Fprintf(writer, "~ %s [%s -> %s]\n", entry, oldProbability, newProbability); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IsTerminal reports whether the entry is a nominal rather than a non-terminal production
+func (e DiffEntry) IsTerminal() bool {
+	return len(e.Left) == 0 && len(e.Right) == 0
+}
+
+// String writes the entry as a production in the format described by ReadGrammar, without its probability
+func (e DiffEntry) String() string {
+	if e.IsTerminal() {
+		return e.Key + " -> " + strconv.Quote(e.Nominal)
+	}
+	return e.Key + " -> " + e.Left + " " + e.Right
+}
+
+// diffIdentity identifies a non-terminal production or a nominal across grammars
+type diffIdentity struct {
+	key     string
+	left    string
+	right   string
+	nominal string
+}
+
+// entry describes the production or nominal with its probabilities in the old and new grammars
+func (i diffIdentity) entry(oldProbability float64, newProbability float64) DiffEntry {
+	return DiffEntry{Key: i.key, Left: i.left, Right: i.right, Nominal: i.nominal, OldProbability: oldProbability, NewProbability: newProbability}
+}
+
+// diffProbabilities finds the probability of each non-terminal production and nominal of a grammar
+// The identities are also returned in the order they first appear, and a repeated one keeps its first probability.
+func diffProbabilities(grammar Grammar) (map[diffIdentity]float64, []diffIdentity) {
+	probabilities := map[diffIdentity]float64{}
+	order := []diffIdentity{}
+	add := func(identity diffIdentity, probability float64) {
+		if _, ok := probabilities[identity]; !ok {
+			probabilities[identity] = probability
+			order = append(order, identity)
+		}
+	}
+	for _, production := range grammar {
+		if len(production.left) > 0 || len(production.right) > 0 {
+			add(diffIdentity{key: production.key, left: production.left, right: production.right}, production.Probability())
+			continue
+		}
+		for _, nominal := range production.nominals {
+			add(diffIdentity{key: production.key, nominal: nominal}, production.NominalProbability(nominal))
+		}
+	}
+	return probabilities, order
+}
+
+// sortDiffEntries sorts entries by key, with non-terminal productions before nominals
+func sortDiffEntries(entries []DiffEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.Key != b.Key {
+			return a.Key < b.Key
+		}
+		if a.IsTerminal() != b.IsTerminal() {
+			return !a.IsTerminal()
+		}
+		if a.Left != b.Left {
+			return a.Left < b.Left
+		}
+		if a.Right != b.Right {
+			return a.Right < b.Right
+		}
+		return a.Nominal < b.Nominal
+	})
+}
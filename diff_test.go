@@ -0,0 +1,111 @@
+package gocky
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	type test struct {
+		name            string
+		oldGrammar      Grammar
+		newGrammar      Grammar
+		expectedAdded   []DiffEntry
+		expectedRemoved []DiffEntry
+		expectedChanged []DiffEntry
+	}
+
+	testCases := []test{
+		{name: "same", oldGrammar: bookFlight(), newGrammar: bookFlight()},
+		{
+			name:       "reordered",
+			oldGrammar: bookFlight(),
+			newGrammar: Grammar{
+				NonterminalProduction("VP", "V", "NP"),
+				TerminalProduction("N", []string{"flight"}),
+				TerminalProduction("DT", []string{"a", "that", "the"}),
+				NonterminalProduction("NP", "DT", "N"),
+				TerminalProduction("V", []string{"book"}),
+				TerminalProduction("N", []string{"book"}),
+			},
+		},
+		{
+			name:       "added and removed",
+			oldGrammar: bookFlight(),
+			newGrammar: Grammar{
+				TerminalProduction("DT", []string{"the", "a"}),
+				TerminalProduction("N", []string{"book", "flight", "trip"}),
+				TerminalProduction("V", []string{"book"}),
+				NonterminalProduction("NP", "DT", "N"),
+				NonterminalProduction("VP", "V", "NP"),
+				NonterminalProduction("S", "NP", "VP"),
+			},
+			expectedAdded: []DiffEntry{
+				{Key: "N", Nominal: "trip", NewProbability: 1},
+				{Key: "S", Left: "NP", Right: "VP", NewProbability: 1},
+			},
+			expectedRemoved: []DiffEntry{
+				{Key: "DT", Nominal: "that", OldProbability: 1},
+			},
+		},
+		{
+			name: "changed probabilities",
+			oldGrammar: Grammar{
+				WeightedTerminalProduction("N", []string{"book", "flight"}, []float64{0.5, 0.5}),
+				NonterminalProduction("NP", "DT", "N"),
+			},
+			newGrammar: Grammar{
+				WeightedTerminalProduction("N", []string{"book", "flight"}, []float64{0.25, 0.5}),
+				WeightedNonterminalProduction("NP", "DT", "N", 1),
+			},
+			expectedChanged: []DiffEntry{
+				{Key: "N", Nominal: "book", OldProbability: 0.5, NewProbability: 0.25},
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		diff := Diff(testCase.oldGrammar, testCase.newGrammar)
+		for _, lists := range []struct {
+			name     string
+			expected []DiffEntry
+			actual   []DiffEntry
+		}{
+			{"added", testCase.expectedAdded, diff.Added},
+			{"removed", testCase.expectedRemoved, diff.Removed},
+			{"changed", testCase.expectedChanged, diff.Changed},
+		} {
+			expected := lists.expected
+			if expected == nil {
+				expected = []DiffEntry{}
+			}
+			if !reflect.DeepEqual(lists.actual, expected) {
+				t.Errorf("(Test \"%s\"), expected %s %v, got %v", testCase.name, lists.name, expected, lists.actual)
+			}
+		}
+		expectedEmpty := len(testCase.expectedAdded)+len(testCase.expectedRemoved)+len(testCase.expectedChanged) == 0
+		if diff.Empty() != expectedEmpty {
+			t.Errorf("(Test \"%s\"), expected Empty to be %t", testCase.name, expectedEmpty)
+		}
+	}
+}
+
+func TestGrammarDiffWrite(t *testing.T) {
+	oldGrammar := Grammar{
+		WeightedNonterminalProduction("NP", "DT", "N", 0.5),
+		TerminalProduction("N", []string{"cat"}),
+	}
+	newGrammar := Grammar{
+		WeightedNonterminalProduction("NP", "DT", "N", 0.75),
+		TerminalProduction("N", []string{"big dog"}),
+	}
+	expected := "- N -> \"cat\"\n+ N -> \"big dog\"\n~ NP -> DT N [0.5 -> 0.75]\n"
+	buffer := &bytes.Buffer{}
+	if err := Diff(oldGrammar, newGrammar).Write(buffer); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if buffer.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buffer.String())
+	}
+}
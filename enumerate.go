@@ -0,0 +1,118 @@
+package gocky
+
+import "strings"
+
+// Enumeration iterates over every sentence a grammar accepts from a start key, shortest sentences first
+// Use Next to advance, then Sentence and Parses to read the current sentence.
+//
+//	enumeration := Enumerate(grammar, "S", 4)
+//	for enumeration.Next() {
+//		fmt.Println(enumeration.Sentence(), len(enumeration.Parses()))
+//	}
+type Enumeration struct {
+	index       *grammarIndex
+	startKey    string
+	maxLength   int
+	derivations [][]derivation
+	sentences   []enumeratedSentence
+	position    int
+}
+
+// derivation is a parse together with the words it covers
+type derivation struct {
+	parse *Parse
+	words []string
+}
+
+// enumeratedSentence is a sentence and its parses from the start key
+type enumeratedSentence struct {
+	words  []string
+	parses []Parse
+}
+
+// Enumerate lists every sentence of up to maxLength words that the grammar accepts from the start key, with its parses.
+// Rather than parsing every combination of nominals, the parses of each length are built from the parses of shorter lengths.
+// Each sentence's parses are in the same order as MatchingParses would return them.
+// Sentences are produced one length at a time, so a grammar accepting many sentences only builds the lengths that are read.
+func Enumerate(grammar Grammar, startKey string, maxLength int) *Enumeration {
+	return &Enumeration{
+		index:       indexGrammar(grammar),
+		startKey:    startKey,
+		maxLength:   maxLength,
+		derivations: [][]derivation{{}},
+		position:    -1,
+	}
+}
+
+// Next advances to the next sentence, returning false once every sentence up to the maximum length has been read
+func (e *Enumeration) Next() bool {
+	e.position++
+	for e.position >= len(e.sentences) {
+		if len(e.derivations) > e.maxLength {
+			return false
+		}
+		e.extend()
+	}
+	return true
+}
+
+// Sentence returns the words of the current sentence
+func (e *Enumeration) Sentence() []string {
+	return e.sentences[e.position].words
+}
+
+// Parses returns the parses of the current sentence from the start key
+func (e *Enumeration) Parses() []Parse {
+	return e.sentences[e.position].parses
+}
+
+// extend builds every derivation of the next length, and queues up the sentences from the start key
+// Nominals of that many words come first, then the non-terminal derivations, the same order the parser fills a chart cell.
+func (e *Enumeration) extend() {
+	length := len(e.derivations)
+	derivations := []derivation{}
+	for productionIndex := range e.index.grammar {
+		production := &e.index.grammar[productionIndex]
+		for nominalIndex, nominal := range production.nominals {
+			words := nominalWords(nominal)
+			if len(words) != length || indexOf(production.nominals, nominal) != nominalIndex {
+				continue
+			}
+			parse, ok := newTerminalParse(production, nominal)
+			if !ok {
+				continue
+			}
+			derivations = append(derivations, derivation{parse: &parse, words: words})
+		}
+	}
+	for splitLength := 1; splitLength < length; splitLength++ {
+		for _, left := range e.derivations[splitLength] {
+			for _, right := range e.derivations[length-splitLength] {
+				for _, parse := range e.index.nonterminalLookup(left.parse, right.parse) {
+					parse := parse
+					words := make([]string, 0, length)
+					words = append(append(words, left.words...), right.words...)
+					derivations = append(derivations, derivation{parse: &parse, words: words})
+				}
+			}
+		}
+	}
+	e.derivations = append(e.derivations, derivations)
+
+	e.sentences = e.sentences[:0]
+	e.position = 0
+	positions := map[string]int{}
+	for _, candidate := range derivations {
+		if candidate.parse.production.key != e.startKey {
+			continue
+		}
+		sentenceKey := strings.Join(candidate.words, "\x00")
+		sentenceIndex, ok := positions[sentenceKey]
+		if !ok {
+			sentenceIndex = len(e.sentences)
+			positions[sentenceKey] = sentenceIndex
+			e.sentences = append(e.sentences, enumeratedSentence{words: candidate.words, parses: []Parse{}})
+		}
+		e.sentences[sentenceIndex].parses = append(e.sentences[sentenceIndex].parses, *candidate.parse)
+	}
+}
@@ -0,0 +1,57 @@
+package gocky
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEnumerate(t *testing.T) {
+	type test struct {
+		name              string
+		grammar           Grammar
+		startKey          string
+		maxLength         int
+		expectedSentences int
+	}
+
+	testCases := []test{
+		{name: "book flight", grammar: bookFlight(), startKey: "VP", maxLength: 3, expectedSentences: 6},
+		{name: "book flight too short", grammar: bookFlight(), startKey: "VP", maxLength: 2, expectedSentences: 0},
+		{name: "big dog", grammar: bigDog(), startKey: "N", maxLength: 3, expectedSentences: 1 + 4 + 16},
+		{name: "panda", grammar: panda(), startKey: "S2", maxLength: 6, expectedSentences: 81},
+		{name: "multi-word nominals", grammar: lookUpNewYork(), startKey: "VP", maxLength: 4, expectedSentences: 6},
+	}
+
+	for _, testCase := range testCases {
+		enumeration := Enumerate(testCase.grammar, testCase.startKey, testCase.maxLength)
+		sentences := 0
+		previousLength := 0
+		for enumeration.Next() {
+			sentences++
+			words := enumeration.Sentence()
+			if len(words) < previousLength {
+				t.Errorf("(Test \"%s\"), sentence %v came after a longer sentence", testCase.name, words)
+			}
+			previousLength = len(words)
+
+			expectedParses := MatchingParses(words, testCase.grammar, []string{testCase.startKey})
+			actualParses := enumeration.Parses()
+			if len(expectedParses) != len(actualParses) {
+				t.Fatalf("(Test \"%s\"), sentence %v expected %d parses, got %d", testCase.name, words, len(expectedParses), len(actualParses))
+			}
+			for parseIndex := range expectedParses {
+				expectedProductionKeys := expectedParses[parseIndex].ProductionKeys()
+				actualProductionKeys := actualParses[parseIndex].ProductionKeys()
+				if !reflect.DeepEqual(expectedProductionKeys, actualProductionKeys) {
+					t.Errorf("(Test \"%s\"), sentence %v expected production keys %v, got %v", testCase.name, words, expectedProductionKeys, actualProductionKeys)
+				}
+			}
+		}
+		if sentences != testCase.expectedSentences {
+			t.Errorf("(Test \"%s\"), expected %d sentences, got %d", testCase.name, testCase.expectedSentences, sentences)
+		}
+		if enumeration.Next() {
+			t.Errorf("(Test \"%s\"), Next should keep returning false once finished", testCase.name)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package gocky
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+// ErrFeatureClash is returned by Generator.Generate when the productions it chose have features that do not unify
+var ErrFeatureClash = errors.New("gocky: generated features clash")
+
+// Features are attribute-value pairs describing a node, like number=plural or person=third
+// A missing attribute is unspecified, and unifies with any value.
+type Features map[string]string
+
+// Unify combines two sets of features, reporting false if they give different values to the same attribute
+func (f Features) Unify(other Features) (Features, bool) {
+	if len(other) == 0 {
+		return f, true
+	}
+	if len(f) == 0 {
+		return other, true
+	}
+	unified := Features{}
+	for name, value := range f {
+		unified[name] = value
+	}
+	for name, value := range other {
+		if existing, ok := unified[name]; ok && existing != value {
+			return nil, false
+		}
+		unified[name] = value
+	}
+	return unified, true
+}
+
+// Equal reports whether two sets of features give the same values to the same attributes
+// A nil set is equal to an empty one.
+func (f Features) Equal(other Features) bool {
+	if len(f) != len(other) {
+		return false
+	}
+	for name, value := range f {
+		if otherValue, ok := other[name]; !ok || otherValue != value {
+			return false
+		}
+	}
+	return true
+}
+
+// canonical writes the features as name=value pairs sorted by name, so that equal features give equal strings
+func (f Features) canonical() string {
+	if len(f) == 0 {
+		return ""
+	}
+	pairs := make([]string, 0, len(f))
+	for name, value := range f {
+		pairs = append(pairs, name+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "\x00")
+}
+
+// WithFeatures returns a copy of the production whose nodes always have the features
+func (p Production) WithFeatures(features Features) Production {
+	p.features = features
+	return p
+}
+
+// WithNominalFeatures returns a copy of the production where nodes for the nominal also have the features
+// The nominal's features are unified with the production's own features.
+func (p Production) WithNominalFeatures(nominal string, features Features) Production {
+	nominalFeatures := map[string]Features{}
+	for existingNominal, existingFeatures := range p.nominalFeatures {
+		nominalFeatures[existingNominal] = existingFeatures
+	}
+	nominalFeatures[nominal] = features
+	p.nominalFeatures = nominalFeatures
+	return p
+}
+
+// WithAgreement returns a copy of the non-terminal production whose components must agree on the named features
+// The agreed values are passed up to the node, so "the dogs" can pass number=plural on to a sentence that checks it against the verb.
+func (p Production) WithAgreement(names ...string) Production {
+	p.agreement = append(append([]string{}, p.agreement...), names...)
+	return p
+}
+
+// WithHeadFeatures returns a copy of the non-terminal production that passes the named features up from its head component
+// Unlike agreement, the other component's features are not checked.
+func (p Production) WithHeadFeatures(head HeadDirection, names ...string) Production {
+	p.headDirection = head
+	p.headFeatures = append(append([]string{}, p.headFeatures...), names...)
+	return p
+}
+
+// Features returns the features of the node
+func (p *Parse) Features() Features {
+	return p.features
+}
+
+// newTerminalParse builds a terminal node, reporting false if the production and nominal features clash
+func newTerminalParse(production *Production, nominal string) (Parse, bool) {
+	features, ok := production.features.Unify(production.nominalFeatures[nominal])
+	if !ok {
+		return Parse{}, false
+	}
+	return Parse{production: production, terminal: nominal, features: features}, true
+}
+
+// newNonterminalParse builds a non-terminal node, reporting false if its components' features clash
+func newNonterminalParse(production *Production, left *Parse, right *Parse) (Parse, bool) {
+	features := production.features
+	for _, name := range production.agreement {
+		for _, component := range []*Parse{left, right} {
+			value, ok := component.features[name]
+			if !ok {
+				continue
+			}
+			if features, ok = features.Unify(Features{name: value}); !ok {
+				return Parse{}, false
+			}
+		}
+	}
+	head := left
+	if production.headDirection == HeadRight {
+		head = right
+	}
+	for _, name := range production.headFeatures {
+		value, ok := head.features[name]
+		if !ok {
+			continue
+		}
+		if features, ok = features.Unify(Features{name: value}); !ok {
+			return Parse{}, false
+		}
+	}
+	return Parse{production: production, left: left, right: right, features: features}, true
+}
@@ -0,0 +1,153 @@
+package gocky
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func agreement() Grammar {
+	singular := Features{"number": "singular"}
+	plural := Features{"number": "plural"}
+	return Grammar{
+		TerminalProduction("DT", []string{"the", "a", "these"}).
+			WithNominalFeatures("a", singular).
+			WithNominalFeatures("these", plural),
+		TerminalProduction("N", []string{"dog", "dogs", "cat", "cats"}).
+			WithNominalFeatures("dog", singular).
+			WithNominalFeatures("dogs", plural).
+			WithNominalFeatures("cat", singular).
+			WithNominalFeatures("cats", plural),
+		TerminalProduction("V", []string{"barks", "bark", "chases", "chase"}).
+			WithFeatures(Features{"person": "third"}).
+			WithNominalFeatures("barks", singular).
+			WithNominalFeatures("bark", plural).
+			WithNominalFeatures("chases", singular).
+			WithNominalFeatures("chase", plural),
+		NonterminalProduction("NP", "DT", "N").WithAgreement("number"),
+		NonterminalProduction("VP", "V", "NP").WithHeadFeatures(HeadLeft, "number"),
+		NonterminalProduction("S", "NP", "V").WithAgreement("number"),
+		NonterminalProduction("S", "NP", "VP").WithAgreement("number"),
+	}
+}
+
+func TestFeatureAgreement(t *testing.T) {
+	type test struct {
+		sentence         []string
+		expectedParses   int
+		expectedFeatures Features
+	}
+
+	testCases := []test{
+		{sentence: []string{"the", "dog", "barks"}, expectedParses: 1, expectedFeatures: Features{"number": "singular"}},
+		{sentence: []string{"the", "dogs", "bark"}, expectedParses: 1, expectedFeatures: Features{"number": "plural"}},
+		{sentence: []string{"the", "dogs", "barks"}, expectedParses: 0},
+		{sentence: []string{"the", "dog", "bark"}, expectedParses: 0},
+		{sentence: []string{"a", "dogs", "bark"}, expectedParses: 0},
+		{sentence: []string{"these", "dogs", "chase", "a", "cat"}, expectedParses: 1, expectedFeatures: Features{"number": "plural"}},
+		{sentence: []string{"the", "dog", "chases", "these", "cats"}, expectedParses: 1, expectedFeatures: Features{"number": "singular"}},
+		{sentence: []string{"the", "dog", "chase", "these", "cats"}, expectedParses: 0},
+	}
+
+	for _, testCase := range testCases {
+		parses := Parses(testCase.sentence, agreement())
+		if len(parses) != testCase.expectedParses {
+			t.Fatalf("(Test %v), num parses expected %d, got %d", testCase.sentence, testCase.expectedParses, len(parses))
+		}
+		if len(parses) > 0 && !reflect.DeepEqual(testCase.expectedFeatures, parses[0].Features()) {
+			t.Errorf("(Test %v), expected features %v, got %v", testCase.sentence, testCase.expectedFeatures, parses[0].Features())
+		}
+	}
+
+	verb := Parses([]string{"the", "dog", "barks"}, agreement())[0].Subparses("V")[0]
+	expectedVerbFeatures := Features{"number": "singular", "person": "third"}
+	if !reflect.DeepEqual(expectedVerbFeatures, verb.Features()) {
+		t.Errorf("Expected verb features %v, got %v", expectedVerbFeatures, verb.Features())
+	}
+}
+
+func TestFeaturesUnify(t *testing.T) {
+	type test struct {
+		name            string
+		left            Features
+		right           Features
+		expectedUnified Features
+		expectedOk      bool
+	}
+
+	testCases := []test{
+		{name: "empty", left: nil, right: nil, expectedUnified: nil, expectedOk: true},
+		{name: "one side", left: Features{"number": "plural"}, right: nil, expectedUnified: Features{"number": "plural"}, expectedOk: true},
+		{name: "merge", left: Features{"number": "plural"}, right: Features{"person": "first"}, expectedUnified: Features{"number": "plural", "person": "first"}, expectedOk: true},
+		{name: "agree", left: Features{"number": "plural"}, right: Features{"number": "plural"}, expectedUnified: Features{"number": "plural"}, expectedOk: true},
+		{name: "clash", left: Features{"number": "plural"}, right: Features{"number": "singular"}, expectedUnified: nil, expectedOk: false},
+	}
+
+	for _, testCase := range testCases {
+		unified, ok := testCase.left.Unify(testCase.right)
+		if ok != testCase.expectedOk || !reflect.DeepEqual(testCase.expectedUnified, unified) {
+			t.Errorf("(Test \"%s\"), expected %v %v, got %v %v", testCase.name, testCase.expectedUnified, testCase.expectedOk, unified, ok)
+		}
+	}
+}
+
+func TestFeaturesDeduplicate(t *testing.T) {
+	// The two NP productions build the same structure for "the sheep", and only the singular one agrees with "barks"
+	grammar := Grammar{
+		TerminalProduction("DT", []string{"the"}),
+		TerminalProduction("N", []string{"sheep"}),
+		TerminalProduction("V", []string{"barks"}).WithFeatures(Features{"num": "sg"}),
+		NonterminalProduction("NP", "DT", "N").WithFeatures(Features{"num": "pl"}),
+		NonterminalProduction("NP", "DT", "N").WithFeatures(Features{"num": "sg"}),
+		NonterminalProduction("S", "NP", "V").WithAgreement("num"),
+	}
+	words := []string{"the", "sheep", "barks"}
+
+	for _, deduplicate := range []bool{false, true} {
+		for _, workers := range []int{1, 2} {
+			parses, err := ParsesContext(context.Background(), words, grammar, ParseOptions{Deduplicate: deduplicate, Workers: workers})
+			if err != nil {
+				t.Fatalf("(Deduplicate %t, Workers %d), unexpected error %v", deduplicate, workers, err)
+			}
+			if len(parses) != 1 {
+				t.Errorf("(Deduplicate %t, Workers %d), num parses expected 1, got %d", deduplicate, workers, len(parses))
+			}
+		}
+	}
+
+	nounPhrases := BuildChart(words[:2], grammar).Cell(0, 2)
+	if len(nounPhrases) != 2 {
+		t.Fatalf("Expected 2 noun phrases, got %d", len(nounPhrases))
+	}
+	if nounPhrases[0].Equal(&nounPhrases[1]) || nounPhrases[0].Hash() == nounPhrases[1].Hash() {
+		t.Errorf("Expected noun phrases with different features to differ")
+	}
+	if deduplicated := Deduplicate(nounPhrases); len(deduplicated) != 2 {
+		t.Errorf("Expected Deduplicate to keep both noun phrases, got %d", len(deduplicated))
+	}
+}
+
+func TestFeaturesEnumerateAndGenerate(t *testing.T) {
+	enumeration := Enumerate(agreement(), "S", 3)
+	for enumeration.Next() {
+		if len(Parses(enumeration.Sentence(), agreement())) != len(enumeration.Parses()) {
+			t.Errorf("Enumerated %v, which does not parse the same way", enumeration.Sentence())
+		}
+	}
+
+	generator := NewGenerator(agreement(), rand.NewSource(5), 5)
+	for sample := 0; sample < 20; sample++ {
+		words, _, err := generator.Generate("S")
+		if errors.Is(err, ErrFeatureClash) {
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Unexpected error %v", err)
+		}
+		if len(Parses(words, agreement())) == 0 {
+			t.Errorf("Generated %v, which the grammar cannot parse", words)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package gocky
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+)
+
+// ErrDepthExceeded is returned by Generator.Generate when a sentence would need a deeper tree than the depth limit
+var ErrDepthExceeded = errors.New("gocky: generation exceeded the depth limit")
+
+// Generator samples random sentences from a grammar
+// A Generator is not safe for concurrent use, because it shares a single random source.
+type Generator struct {
+	alternatives map[string][]alternative
+	random       *rand.Rand
+	maxDepth     int
+}
+
+// alternative is one way a key can be produced, either a non-terminal production or one nominal of a terminal production
+type alternative struct {
+	production *Production
+	nominal    string
+	weight     float64
+}
+
+// NewGenerator creates a Generator for the grammar
+// The source makes generation reproducible, and maxDepth limits the depth of the generated trees.
+// A maxDepth of zero or less does not limit the depth, which may never finish for recursive grammars.
+// Productions that can never produce any words, such as those with a component key the grammar does not define, are never chosen.
+func NewGenerator(grammar Grammar, source rand.Source, maxDepth int) *Generator {
+	generator := &Generator{
+		alternatives: map[string][]alternative{},
+		random:       rand.New(source),
+		maxDepth:     maxDepth,
+	}
+	productive := productiveKeys(grammar)
+	for productionIndex := range grammar {
+		production := &grammar[productionIndex]
+		if len(production.left) > 0 || len(production.right) > 0 {
+			if !productive[production.left] || !productive[production.right] {
+				continue
+			}
+			generator.alternatives[production.key] = append(generator.alternatives[production.key], alternative{
+				production: production,
+				weight:     production.Probability(),
+			})
+			continue
+		}
+		for nominalIndex, nominal := range production.nominals {
+			if indexOf(production.nominals, nominal) != nominalIndex {
+				continue
+			}
+			generator.alternatives[production.key] = append(generator.alternatives[production.key], alternative{
+				production: production,
+				nominal:    nominal,
+				weight:     production.NominalProbability(nominal),
+			})
+		}
+	}
+	return generator
+}
+
+// productiveKeys finds the keys that can produce at least one sequence of words
+func productiveKeys(grammar Grammar) map[string]bool {
+	productive := map[string]bool{}
+	for changed := true; changed; {
+		changed = false
+		for _, production := range grammar {
+			if productive[production.key] {
+				continue
+			}
+			if len(production.nominals) > 0 || (productive[production.left] && productive[production.right]) {
+				productive[production.key] = true
+				changed = true
+			}
+		}
+	}
+	return productive
+}
+
+// Generate samples a sentence from the start key, returning its words and the parse that generated them
+// Alternatives for a key are chosen by their probabilities when every one of them is weighted, and uniformly otherwise.
+// Generation stops with ErrDepthExceeded when the tree grows deeper than the depth limit,
+// and with ErrFeatureClash when the chosen productions' features do not unify. Calling Generate again samples afresh.
+func (g *Generator) Generate(startKey string) ([]string, *Parse, error) {
+	words := []string{}
+	parse, err := g.generate(startKey, 1, &words)
+	if err != nil {
+		return nil, nil, err
+	}
+	return words, parse, nil
+}
+
+// generate samples a parse for the key at the given depth, adding its words to the end of words
+func (g *Generator) generate(key string, depth int, words *[]string) (*Parse, error) {
+	if g.maxDepth > 0 && depth > g.maxDepth {
+		return nil, ErrDepthExceeded
+	}
+	chosen, err := g.choose(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(chosen.nominal) > 0 {
+		*words = append(*words, nominalWords(chosen.nominal)...)
+		parse, ok := newTerminalParse(chosen.production, chosen.nominal)
+		if !ok {
+			return nil, ErrFeatureClash
+		}
+		return &parse, nil
+	}
+	left, err := g.generate(chosen.production.left, depth+1, words)
+	if err != nil {
+		return nil, err
+	}
+	right, err := g.generate(chosen.production.right, depth+1, words)
+	if err != nil {
+		return nil, err
+	}
+	parse, ok := newNonterminalParse(chosen.production, left, right)
+	if !ok {
+		return nil, ErrFeatureClash
+	}
+	return &parse, nil
+}
+
+// choose picks one of the alternatives for a key
+func (g *Generator) choose(key string) (alternative, error) {
+	alternatives := g.alternatives[key]
+	if len(alternatives) == 0 {
+		return alternative{}, fmt.Errorf("gocky: no productions for key %q", key)
+	}
+	total := 0.0
+	for _, candidate := range alternatives {
+		if !candidate.production.weighted {
+			return alternatives[g.random.Intn(len(alternatives))], nil
+		}
+		total += candidate.weight
+	}
+	if total <= 0 {
+		return alternatives[g.random.Intn(len(alternatives))], nil
+	}
+	target := g.random.Float64() * total
+	for _, candidate := range alternatives {
+		target -= candidate.weight
+		if target < 0 {
+			return candidate, nil
+		}
+	}
+	return alternatives[len(alternatives)-1], nil
+}
@@ -0,0 +1,94 @@
+package gocky
+
+import (
+	"errors"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	type test struct {
+		name     string
+		grammar  Grammar
+		startKey string
+	}
+
+	testCases := []test{
+		{name: "book flight", grammar: bookFlight(), startKey: "VP"},
+		{name: "panda", grammar: panda(), startKey: "S2"},
+		{name: "big dog", grammar: bigDog(), startKey: "N"},
+		{name: "multi-word nominals", grammar: lookUpNewYork(), startKey: "VP"},
+	}
+
+	for _, testCase := range testCases {
+		generator := NewGenerator(testCase.grammar, rand.NewSource(1), 20)
+		for sample := 0; sample < 20; sample++ {
+			words, parse, err := generator.Generate(testCase.startKey)
+			if errors.Is(err, ErrDepthExceeded) {
+				continue
+			}
+			if err != nil {
+				t.Fatalf("(Test \"%s\"), unexpected error %v", testCase.name, err)
+			}
+			if parse.Key() != testCase.startKey {
+				t.Errorf("(Test \"%s\"), expected a parse from %s, got %s", testCase.name, testCase.startKey, parse.Key())
+			}
+			if actualWords := parse.ProductionTerminals(testCase.startKey); !reflect.DeepEqual(words, actualWords[len(actualWords)-1]) {
+				t.Errorf("(Test \"%s\"), words %v do not match the parse %v", testCase.name, words, actualWords)
+			}
+			if len(MatchingParses(words, testCase.grammar, []string{testCase.startKey})) == 0 {
+				t.Errorf("(Test \"%s\"), generated %v which the grammar cannot parse", testCase.name, words)
+			}
+		}
+	}
+}
+
+func TestGenerateReproducible(t *testing.T) {
+	first := NewGenerator(bigDog(), rand.NewSource(7), 10)
+	second := NewGenerator(bigDog(), rand.NewSource(7), 10)
+	for sample := 0; sample < 10; sample++ {
+		firstWords, _, firstErr := first.Generate("N")
+		secondWords, _, secondErr := second.Generate("N")
+		if firstErr != nil || secondErr != nil {
+			if !errors.Is(firstErr, ErrDepthExceeded) || !errors.Is(secondErr, ErrDepthExceeded) {
+				t.Errorf("Expected identical errors from identical sources, got %v and %v", firstErr, secondErr)
+			}
+			continue
+		}
+		if !reflect.DeepEqual(firstWords, secondWords) {
+			t.Errorf("Expected identical samples from identical sources, got %v and %v", firstWords, secondWords)
+		}
+	}
+}
+
+func TestGenerateWeighted(t *testing.T) {
+	grammar := Grammar{
+		WeightedTerminalProduction("N", []string{"dog", "cat"}, []float64{1, 0}),
+		WeightedTerminalProduction("V", []string{"barks"}, []float64{1}),
+		WeightedNonterminalProduction("S", "N", "V", 1),
+	}
+	generator := NewGenerator(grammar, rand.NewSource(3), 5)
+	for sample := 0; sample < 20; sample++ {
+		words, _, err := generator.Generate("S")
+		if err != nil {
+			t.Fatalf("Unexpected error %v", err)
+		}
+		if !reflect.DeepEqual(words, []string{"dog", "barks"}) {
+			t.Errorf("Expected only [dog barks], got %v", words)
+		}
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	generator := NewGenerator(bookFlight(), rand.NewSource(1), 2)
+	if _, _, err := generator.Generate("VP"); !errors.Is(err, ErrDepthExceeded) {
+		t.Errorf("Expected ErrDepthExceeded, got %v", err)
+	}
+	if _, _, err := generator.Generate("missing"); err == nil {
+		t.Errorf("Expected an error for a missing key")
+	}
+	if _, _, err := generator.Generate("JJ"); err == nil {
+		t.Errorf("Expected an error for a key without nominals")
+	}
+}
@@ -1,3 +1,3 @@
 module github.com/kstafford3/gocky
 
-go 1.16
+go 1.21
@@ -1,5 +1,11 @@
 package gocky
 
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
 // MatchingParses produces a list of parses based on a list of words, a grammar, and a target production key.
 // Only parses that can be generated from the target production keys will be returned.
 func MatchingParses(words []string, grammar Grammar, targetProductionKeys []string) []Parse {
@@ -16,36 +22,262 @@ func MatchingParses(words []string, grammar Grammar, targetProductionKeys []stri
 // Parses produces a list of parses based on a list of words and a grammar.
 // Each parse will describe a different parse tree for the words based on the grammar.
 func Parses(words []string, grammar Grammar) []Parse {
-	return ckyParse(words, grammar)
+	parses, _ := ckyParse(context.Background(), words, grammar, ParseOptions{})
+	return parses
+}
+
+// ParsesContext produces the same parses as Parses, but gives up when the context is done or a limit in options is exceeded.
+// A cancelled or expired context returns the context's error, an exceeded limit returns a *LimitError.
+func ParsesContext(ctx context.Context, words []string, grammar Grammar, options ParseOptions) ([]Parse, error) {
+	return ckyParse(ctx, words, grammar, options)
+}
+
+// parser holds the state of a CKY parse
+// A parser can be reused for many sentences, one at a time, so that its chart rows are only allocated once.
+type parser struct {
+	index      *grammarIndex
+	ctx        context.Context
+	words      []string
+	options    ParseOptions
+	table      [][][]Parse
+	parseNodes int64
+}
+
+// newParser creates a parser for an indexed grammar
+func newParser(index *grammarIndex) *parser {
+	return &parser{index: index}
 }
 
 // ckyParse performs a parse based on the CKY algorithm.
 // https://en.wikipedia.org/wiki/CYK_algorithm
-func ckyParse(words []string, grammar Grammar) []Parse {
-	table := make([][][]Parse, len(words)+1)
-	for endIndex := 1; endIndex <= len(words); endIndex++ {
-		table[endIndex-1] = make([][]Parse, len(words)+1)
-		table[endIndex-1][endIndex] = terminalLookup(words[endIndex-1], grammar)
-		for startIndex := endIndex - 2; startIndex >= 0; startIndex-- {
-			table[startIndex][endIndex] = []Parse{}
-			for splitIndex := startIndex + 1; splitIndex < endIndex; splitIndex++ {
-				splitProductions := getGeneratingProductions(table[startIndex][splitIndex], table[splitIndex][endIndex], grammar)
-				table[startIndex][endIndex] = append(table[startIndex][endIndex], splitProductions...)
+func ckyParse(ctx context.Context, words []string, grammar Grammar, options ParseOptions) ([]Parse, error) {
+	return newParser(indexGrammar(grammar)).parse(ctx, words, options)
+}
+
+// parse fills the chart for the words and returns the parses spanning all of them
+// The parse is reported to ParseOptions.Tracer when there is one.
+func (p *parser) parse(ctx context.Context, words []string, options ParseOptions) ([]Parse, error) {
+	if options.Tracer == nil {
+		return p.fillChart(ctx, words, options)
+	}
+	options.Tracer.ParseStarted(words)
+	parses, err := p.fillChart(ctx, words, options)
+	options.Tracer.ParseFinished(words, parses, err)
+	return parses, err
+}
+
+// fillChart fills the chart for the words and returns the parses spanning all of them
+//
+// The chart is filled one diagonal at a time, from single words up to the whole sentence.
+// Every cell on a diagonal spans the same number of words, so the cells only depend on shorter diagonals.
+// Multi-word nominals are placed in the cells they span before the diagonals are filled.
+func (p *parser) fillChart(ctx context.Context, words []string, options ParseOptions) ([]Parse, error) {
+	if options.MaxWords > 0 && len(words) > options.MaxWords {
+		return nil, &LimitError{Limit: LimitWords, Max: options.MaxWords}
+	}
+	if len(words) == 0 {
+		return []Parse{}, nil
+	}
+	p.ctx = ctx
+	p.words = words
+	p.options = options
+	p.parseNodes = 0
+	p.resetTable()
+	for startIndex, word := range words {
+		terminalParses := p.index.terminalLookup(word)
+		p.table[startIndex][startIndex+1] = p.deduplicate(terminalParses)
+		if options.Tracer != nil {
+			p.traceTerminals(word, startIndex, startIndex+1, terminalParses, len(p.index.terminals[word]))
+			p.traceCell(startIndex, startIndex+1, len(terminalParses))
+		}
+		if err := p.checkCellSize(len(terminalParses)); err != nil {
+			return nil, err
+		}
+		if err := p.addParseNodes(len(p.table[startIndex][startIndex+1])); err != nil {
+			return nil, err
+		}
+	}
+	for startIndex := range words {
+		phraseParses, lengths := p.index.phraseLookup(words[startIndex:])
+		for phraseIndex, phraseParse := range phraseParses {
+			endIndex := startIndex + lengths[phraseIndex]
+			p.table[startIndex][endIndex] = append(p.table[startIndex][endIndex], phraseParse)
+			if err := p.checkCellSize(len(p.table[startIndex][endIndex])); err != nil {
+				return nil, err
 			}
 		}
+		if options.Tracer != nil {
+			p.tracePhrases(startIndex, phraseParses, lengths)
+		}
+	}
+	for spanLength := 2; spanLength <= len(words); spanLength++ {
+		if err := p.fillDiagonal(spanLength); err != nil {
+			return nil, err
+		}
+	}
+	return p.table[0][len(words)], nil
+}
+
+// resetTable sizes the chart for the current words, reusing the rows of previous parses where possible
+// The cells themselves are never reused, because the returned parses point into them.
+func (p *parser) resetTable() {
+	size := len(p.words) + 1
+	if cap(p.table) < size {
+		p.table = make([][][]Parse, size)
+	}
+	p.table = p.table[:size]
+	for startIndex := range p.table {
+		row := p.table[startIndex]
+		if cap(row) < size {
+			row = make([][]Parse, size)
+		}
+		row = row[:size]
+		for endIndex := range row {
+			row[endIndex] = nil
+		}
+		p.table[startIndex] = row
 	}
-	return table[0][len(words)]
+}
+
+// fillDiagonal fills every cell spanning spanLength words
+// When ParseOptions.Workers is more than one, the cells are shared between that many goroutines.
+// Each cell is always built in the same order, so the results do not depend on the number of workers.
+// The first error stops every worker before its next cell, and is the one returned.
+func (p *parser) fillDiagonal(spanLength int) error {
+	cells := len(p.words) - spanLength + 1
+	workers := p.options.Workers
+	if workers > cells {
+		workers = cells
+	}
+	if workers <= 1 {
+		for startIndex := 0; startIndex < cells; startIndex++ {
+			if err := p.fillCell(p.ctx, startIndex, startIndex+spanLength); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(p.ctx)
+	defer cancel()
+	var firstErr error
+	var failOnce sync.Once
+	var wg sync.WaitGroup
+	for worker := 0; worker < workers; worker++ {
+		wg.Add(1)
+		go func(firstIndex int) {
+			defer wg.Done()
+			for startIndex := firstIndex; startIndex < cells; startIndex += workers {
+				if err := p.fillCell(ctx, startIndex, startIndex+spanLength); err != nil {
+					failOnce.Do(func() {
+						firstErr = err
+						cancel()
+					})
+					return
+				}
+			}
+		}(worker)
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// fillCell builds every parse spanning the words from startIndex up to endIndex
+// Parses of multi-word nominals already in the cell come first.
+// The context is checked before the cell is started, so that workers stop once another has failed.
+func (p *parser) fillCell(ctx context.Context, startIndex int, endIndex int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	cell := append([]Parse{}, p.table[startIndex][endIndex]...)
+	var splits []int
+	if p.options.Tracer != nil {
+		splits = make([]int, len(cell))
+	}
+	for splitIndex := startIndex + 1; splitIndex < endIndex; splitIndex++ {
+		splitProductions := getGeneratingProductions(p.table[startIndex][splitIndex], p.table[splitIndex][endIndex], p.index)
+		if p.options.Tracer != nil {
+			p.traceSplit(startIndex, splitIndex, endIndex, splitProductions)
+			for range splitProductions {
+				splits = append(splits, splitIndex)
+			}
+		}
+		cell = append(cell, splitProductions...)
+		if err := p.checkCellSize(len(cell)); err != nil {
+			return err
+		}
+	}
+	p.table[startIndex][endIndex] = p.deduplicate(cell)
+	if p.options.Tracer != nil {
+		p.traceConstituents(startIndex, endIndex, cell, splits)
+		p.traceCell(startIndex, endIndex, len(cell))
+	}
+	return p.addParseNodes(len(p.table[startIndex][endIndex]))
+}
+
+// cellIdentity identifies a parse within a deduplicated chart cell
+type cellIdentity struct {
+	key      string
+	terminal string
+	features string
+	left     *Parse
+	right    *Parse
+}
+
+// deduplicate drops structural duplicates from a cell when ParseOptions.Deduplicate is set
+func (p *parser) deduplicate(cell []Parse) []Parse {
+	if !p.options.Deduplicate {
+		return cell
+	}
+	kept := distinctParses(cell)
+	distinct := make([]Parse, 0, len(kept))
+	for _, parseIndex := range kept {
+		distinct = append(distinct, cell[parseIndex])
+	}
+	return distinct
+}
+
+// distinctParses returns the positions of the first of each structurally equal parse in a cell
+// The cells a parse is built from have already been deduplicated, so structurally equal components are the same node.
+// That lets two parses in a cell be compared by key, terminal, features and component addresses, rather than walking their trees.
+func distinctParses(cell []Parse) []int {
+	kept := make([]int, 0, len(cell))
+	seen := map[cellIdentity]bool{}
+	for parseIndex, parse := range cell {
+		identity := cellIdentity{key: parse.production.key, terminal: parse.terminal, features: parse.features.canonical(), left: parse.left, right: parse.right}
+		if !seen[identity] {
+			seen[identity] = true
+			kept = append(kept, parseIndex)
+		}
+	}
+	return kept
+}
+
+// checkCellSize checks the number of parses built for one cell against ParseOptions.MaxCellSize
+func (p *parser) checkCellSize(size int) error {
+	if p.options.MaxCellSize > 0 && size > p.options.MaxCellSize {
+		return &LimitError{Limit: LimitCellSize, Max: p.options.MaxCellSize}
+	}
+	return nil
+}
+
+// addParseNodes counts parses added to the chart against ParseOptions.MaxParseNodes
+func (p *parser) addParseNodes(count int) error {
+	parseNodes := atomic.AddInt64(&p.parseNodes, int64(count))
+	if p.options.MaxParseNodes > 0 && parseNodes > int64(p.options.MaxParseNodes) {
+		return &LimitError{Limit: LimitParseNodes, Max: p.options.MaxParseNodes}
+	}
+	return nil
 }
 
 // getGeneratingProductions takes two parses and generates a list of parses that that could explain them as left and right components of a Production
-//
-func getGeneratingProductions(leftParses []Parse, rightParses []Parse, grammar Grammar) []Parse {
+func getGeneratingProductions(leftParses []Parse, rightParses []Parse, index *grammarIndex) []Parse {
 	allProductions := []Parse{}
 	for leftParseIndex := range leftParses {
 		leftParse := &leftParses[leftParseIndex]
 		for rightParseIndex := range rightParses {
 			rightParse := &rightParses[rightParseIndex]
-			localProductions := nonterminalLookup(leftParse, rightParse, grammar)
+			localProductions := index.nonterminalLookup(leftParse, rightParse)
 			allProductions = append(allProductions, localProductions...)
 		}
 	}
@@ -1,6 +1,8 @@
 package gocky
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"reflect"
 	"regexp"
@@ -67,6 +69,20 @@ func bigDog() Grammar {
 	}
 }
 
+// lookUpNewYork has multi-word nominals that overlap the single words they are made of
+func lookUpNewYork() Grammar {
+	return Grammar{
+		TerminalProduction("V", []string{"look up", "look"}),
+		TerminalProduction("P", []string{"up"}),
+		TerminalProduction("N", []string{"New York", "York"}),
+		TerminalProduction("J", []string{"New"}),
+		NonterminalProduction("N", "J", "N"),
+		NonterminalProduction("PP", "P", "N"),
+		NonterminalProduction("VP", "V", "N"),
+		NonterminalProduction("VP", "V", "PP"),
+	}
+}
+
 // compareNestedStringArray tests whether two nested string arrays are equivalent
 func compareNestedStringArray(t *testing.T, name string, expected [][]string, actual [][]string) {
 	if !reflect.DeepEqual(expected, actual) {
@@ -192,3 +208,172 @@ func TestParses(t *testing.T) {
 		}
 	}
 }
+
+func TestParsesContext(t *testing.T) {
+	type test struct {
+		name          string
+		sentence      string
+		grammar       Grammar
+		options       ParseOptions
+		expectedParse int
+		expectedLimit Limit
+	}
+
+	testCases := []test{
+		{name: "unlimited", sentence: "the panda eats shoots and leaves", grammar: panda(), expectedParse: 2},
+		{name: "generous", sentence: "the panda eats shoots and leaves", grammar: panda(), options: ParseOptions{MaxWords: 6, MaxCellSize: 10, MaxParseNodes: 100}, expectedParse: 2},
+		{name: "words", sentence: "the panda eats shoots and leaves", grammar: panda(), options: ParseOptions{MaxWords: 5}, expectedLimit: LimitWords},
+		{name: "cell size", sentence: "the panda eats shoots and leaves", grammar: panda(), options: ParseOptions{MaxCellSize: 1}, expectedLimit: LimitCellSize},
+		{name: "cell size of one word", sentence: "shoots", grammar: panda(), options: ParseOptions{MaxCellSize: 1}, expectedLimit: LimitCellSize},
+		{name: "cell size of a phrase", sentence: "look up New York", grammar: append(lookUpNewYork(), TerminalProduction("V", []string{"New York"})), options: ParseOptions{MaxCellSize: 1}, expectedLimit: LimitCellSize},
+		{name: "parse nodes", sentence: "the panda eats shoots and leaves", grammar: panda(), options: ParseOptions{MaxParseNodes: 8}, expectedLimit: LimitParseNodes},
+		{name: "cell size with workers", sentence: "the shoots and leaves eats shoots and leaves", grammar: panda(), options: ParseOptions{MaxCellSize: 1, Workers: 4}, expectedLimit: LimitCellSize},
+		{name: "parse nodes with workers", sentence: "the big gray furry big gray furry dog", grammar: bigDog(), options: ParseOptions{MaxParseNodes: 12, Workers: 4}, expectedLimit: LimitParseNodes},
+	}
+
+	for _, testCase := range testCases {
+		words := regexp.MustCompile("\\s+").Split(testCase.sentence, -1)
+		actualParses, err := ParsesContext(context.Background(), words, testCase.grammar, testCase.options)
+		if testCase.expectedLimit != 0 {
+			var limitErr *LimitError
+			if !errors.As(err, &limitErr) || limitErr.Limit != testCase.expectedLimit {
+				t.Errorf("(Test \"%s\"), expected %s error, got %v", testCase.name, testCase.expectedLimit, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("(Test \"%s\"), unexpected error %v", testCase.name, err)
+		}
+		if len(actualParses) != testCase.expectedParse {
+			t.Errorf("(Test \"%s\"), num parses expected %d, got %d", testCase.name, testCase.expectedParse, len(actualParses))
+		}
+	}
+}
+
+func TestParsesContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	words := []string{"the", "panda", "eats", "shoots", "and", "leaves"}
+	if _, err := ParsesContext(ctx, words, panda(), ParseOptions{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestParsesEmpty(t *testing.T) {
+	if parses := Parses([]string{}, panda()); len(parses) != 0 {
+		t.Errorf("Expected no parses for an empty sentence, got %d", len(parses))
+	}
+}
+
+func TestParsesContextWorkers(t *testing.T) {
+	type test struct {
+		sentence string
+		grammar  Grammar
+	}
+
+	testCases := []test{
+		{sentence: "book that flight", grammar: bookFlight()},
+		{sentence: "the panda eats shoots and leaves", grammar: panda()},
+		{sentence: "the shoots and leaves eats shoots and leaves", grammar: panda()},
+		{sentence: "the big gray furry big gray furry dog", grammar: bigDog()},
+	}
+
+	for _, testCase := range testCases {
+		words := regexp.MustCompile("\\s+").Split(testCase.sentence, -1)
+		serialParses := Parses(words, testCase.grammar)
+		for workers := 2; workers <= 8; workers++ {
+			parallelParses, err := ParsesContext(context.Background(), words, testCase.grammar, ParseOptions{Workers: workers})
+			if err != nil {
+				t.Fatalf("(Test \"%s\"), unexpected error %v with %d workers", testCase.sentence, err, workers)
+			}
+			if len(serialParses) != len(parallelParses) {
+				t.Fatalf("(Test \"%s\"), num parses expected %d, got %d with %d workers", testCase.sentence, len(serialParses), len(parallelParses), workers)
+			}
+			for parseIndex := range serialParses {
+				expectedProductionKeys := serialParses[parseIndex].ProductionKeys()
+				actualProductionKeys := parallelParses[parseIndex].ProductionKeys()
+				if !reflect.DeepEqual(expectedProductionKeys, actualProductionKeys) {
+					t.Errorf("(Test \"%s\"), expected production keys %v, but got %v with %d workers", testCase.sentence, expectedProductionKeys, actualProductionKeys, workers)
+				}
+			}
+		}
+	}
+}
+
+func TestParsesContextDeduplicate(t *testing.T) {
+	redundantPanda := append(panda(),
+		Production{key: "N", nominals: []string{"panda", "leaves"}},
+		Production{key: "DN0", left: "DT", right: "N"},
+	)
+	words := []string{"the", "panda", "eats", "shoots", "and", "leaves"}
+
+	duplicatedParses := Parses(words, redundantPanda)
+	if len(duplicatedParses) <= 2 {
+		t.Fatalf("Expected duplicate parses from the redundant grammar, got %d", len(duplicatedParses))
+	}
+
+	for workers := 1; workers <= 3; workers++ {
+		actualParses, err := ParsesContext(context.Background(), words, redundantPanda, ParseOptions{Deduplicate: true, Workers: workers})
+		if err != nil {
+			t.Fatalf("Unexpected error %v", err)
+		}
+		expectedParses := Parses(words, panda())
+		if len(actualParses) != len(expectedParses) {
+			t.Fatalf("(Workers %d), num parses expected %d, got %d", workers, len(expectedParses), len(actualParses))
+		}
+		for parseIndex := range expectedParses {
+			if !expectedParses[parseIndex].Equal(&actualParses[parseIndex]) {
+				t.Errorf("(Workers %d), expected production keys %v, but got %v", workers, expectedParses[parseIndex].ProductionKeys(), actualParses[parseIndex].ProductionKeys())
+			}
+		}
+	}
+
+	if distinctParses := Deduplicate(duplicatedParses); len(distinctParses) != 2 {
+		t.Errorf("Expected 2 distinct parses, got %d", len(distinctParses))
+	}
+}
+
+func TestParsesMultiWordNominals(t *testing.T) {
+	words := []string{"look", "up", "New", "York"}
+	expectedParses := []string{
+		"(VP (V look) (PP (P up) (N New York)))",
+		"(VP (V look) (PP (P up) (N (J New) (N York))))",
+		"(VP (V look up) (N New York))",
+		"(VP (V look up) (N (J New) (N York)))",
+	}
+
+	for workers := 1; workers <= 3; workers++ {
+		parses, err := ParsesContext(context.Background(), words, lookUpNewYork(), ParseOptions{Workers: workers})
+		if err != nil {
+			t.Fatalf("Unexpected error %v", err)
+		}
+		actualParses := []string{}
+		for parseIndex := range parses {
+			actualParses = append(actualParses, parses[parseIndex].String())
+			if !reflect.DeepEqual(parses[parseIndex].Words(), words) {
+				t.Errorf("(Workers %d), expected words %v, got %v", workers, words, parses[parseIndex].Words())
+			}
+		}
+		if !reflect.DeepEqual(actualParses, expectedParses) {
+			t.Fatalf("(Workers %d), expected parses %v, got %v", workers, expectedParses, actualParses)
+		}
+	}
+
+	parse := &Parses(words, lookUpNewYork())[2]
+	compareNestedStringArray(t, "multi-word nouns", [][]string{{"New", "York"}}, parse.ProductionTerminals("N"))
+	compareNestedStringArray(t, "multi-word verbs", [][]string{{"look", "up"}}, parse.ProductionTerminals("V"))
+	leaves := parse.Leaves()
+	if len(leaves) != 2 || leaves[0].Start != 0 || leaves[0].End != 2 || leaves[1].Start != 2 || leaves[1].End != 4 {
+		t.Errorf("Expected leaves spanning 0-2 and 2-4, got %v", leaves)
+	}
+
+	if parses := Parses([]string{"New"}, lookUpNewYork()); len(parses) != 1 || parses[0].Key() != "J" {
+		t.Errorf("Expected only the single word nominal for part of a phrase, got %v", parses)
+	}
+	if parses := Parses([]string{"New", "York"}, Grammar{TerminalProduction("N", []string{"  New   York "})}); len(parses) != 1 || parses[0].Terminal() != "  New   York " {
+		t.Errorf("Expected a nominal with extra spaces to match its words, got %v", parses)
+	}
+	if parses := Parses([]string{"New York"}, lookUpNewYork()); len(parses) != 0 {
+		t.Errorf("Expected a single word containing a space not to match a phrase, got %v", parses)
+	}
+}
@@ -1,5 +1,10 @@
 package gocky
 
+import (
+	"fmt"
+	"strings"
+)
+
 // Grammar holds the productions for a context free grammar in chomsky normal form
 type Grammar []Production
 
@@ -15,11 +20,28 @@ type Grammar []Production
 // Left and Right Keys:
 // References to component productions
 // For example, he Production "noun phrase" might have a left "article" and a right "noun"
+//
+// Weighted productions also carry probabilities, making the grammar a probabilistic context free grammar.
+// A non-terminal production has a single probability, a terminal production has a probability for each nominal.
+//
+// Productions can also carry a SemanticFunc, which Parse.Evaluate uses to compute the meaning of the nodes they generate.
+//
+// Finally, productions can carry Features, with constraints on the features of their components.
+// Nodes whose features clash are discarded while parsing.
 type Production struct {
-	key      string
-	left     string
-	right    string
-	nominals []string
+	key                  string
+	left                 string
+	right                string
+	nominals             []string
+	weighted             bool
+	probability          float64
+	nominalProbabilities []float64
+	semantics            SemanticFunc
+	features             Features
+	nominalFeatures      map[string]Features
+	agreement            []string
+	headDirection        HeadDirection
+	headFeatures         []string
 }
 
 // NonterminalProduction creates a non-terminal production in the chomsky normal form
@@ -37,6 +59,7 @@ func NonterminalProduction(key string, left string, right string) Production {
 
 // TerminalProduction creates a terminal production in the chomsky normal form
 // These productions describe a set of sting literals
+// A nominal can hold several words separated by spaces, such as "New York", and then matches those words in sequence as a single node.
 func TerminalProduction(key string, nominals []string) Production {
 	return Production{
 		key:      key,
@@ -44,28 +67,275 @@ func TerminalProduction(key string, nominals []string) Production {
 	}
 }
 
-// terminalLookup returns a list of Parses for a given nominal
-func terminalLookup(nominal string, grammar Grammar) []Parse {
-	matchingParses := []Parse{}
+// WeightedNonterminalProduction creates a non-terminal production with the probability of its key producing the left and right components
+func WeightedNonterminalProduction(key string, left string, right string, probability float64) Production {
+	production := NonterminalProduction(key, left, right)
+	production.weighted = true
+	production.probability = probability
+	return production
+}
+
+// WeightedTerminalProduction creates a terminal production with the probability of its key producing each nominal
+// There should be exactly one probability per nominal. Grammar.Validate reports a production without,
+// and a nominal without a probability has a probability of 0.
+func WeightedTerminalProduction(key string, nominals []string, probabilities []float64) Production {
+	production := TerminalProduction(key, nominals)
+	production.weighted = true
+	production.nominalProbabilities = append([]float64{}, probabilities...)
+	return production
+}
+
+// Weighted reports whether the production carries probabilities
+func (p Production) Weighted() bool {
+	return p.weighted
+}
+
+// Probability returns the probability of a non-terminal production
+// Unweighted productions have a probability of 1.
+func (p Production) Probability() float64 {
+	if !p.weighted {
+		return 1
+	}
+	return p.probability
+}
+
+// NominalProbability returns the probability of a terminal production producing the nominal
+// Unweighted productions have a probability of 1 for each of their nominals, and 0 for anything else.
+func (p Production) NominalProbability(nominal string) float64 {
+	for nominalIndex, candidate := range p.nominals {
+		if candidate == nominal {
+			if !p.weighted {
+				return 1
+			}
+			return p.nominalProbabilityAt(nominalIndex)
+		}
+	}
+	return 0
+}
+
+// nominalProbabilityAt returns the probability of the nominal at nominalIndex, or 0 when it was given none
+func (p Production) nominalProbabilityAt(nominalIndex int) float64 {
+	if nominalIndex >= len(p.nominalProbabilities) {
+		return 0
+	}
+	return p.nominalProbabilities[nominalIndex]
+}
+
+// grammarIndex looks up the productions of a grammar by nominal or by component keys
+// An index is built once per grammar and can be shared between parses
+type grammarIndex struct {
+	grammar      Grammar
+	terminals    map[string][]indexedNominal
+	phrases      map[string][]indexedPhrase
+	nonterminals map[componentKeys][]*Production
+}
+
+// indexedNominal is a nominal of a terminal production, as it is written in the production
+type indexedNominal struct {
+	production *Production
+	nominal    string
+}
+
+// indexedPhrase is a multi-word nominal's words, with every production nominal made of them
+type indexedPhrase struct {
+	words    []string
+	nominals []indexedNominal
+}
+
+// componentKeys are the left and right keys of a non-terminal production
+type componentKeys struct {
+	left  string
+	right string
+}
+
+// indexGrammar builds a grammarIndex, keeping productions in the order they appear in the grammar
+// Single-word nominals are indexed by their word, and multi-word nominals are indexed as phrases under their first word.
+// Keeping them apart means a single word that happens to contain a space never matches a phrase.
+func indexGrammar(grammar Grammar) *grammarIndex {
+	index := &grammarIndex{
+		grammar:      grammar,
+		terminals:    map[string][]indexedNominal{},
+		phrases:      map[string][]indexedPhrase{},
+		nonterminals: map[componentKeys][]*Production{},
+	}
 	for productionIndex := range grammar {
 		production := &grammar[productionIndex]
-		if contains(production.nominals, nominal) {
-			node := Parse{production: production, terminal: nominal}
+		for _, nominal := range production.nominals {
+			words := nominalWords(nominal)
+			if len(words) == 1 {
+				index.terminals[words[0]] = appendNominal(index.terminals[words[0]], production, nominal)
+			} else {
+				index.addPhrase(words, production, nominal)
+			}
+		}
+		if len(production.left) > 0 || len(production.right) > 0 {
+			components := componentKeys{left: production.left, right: production.right}
+			index.nonterminals[components] = append(index.nonterminals[components], production)
+		}
+	}
+	return index
+}
+
+// addPhrase indexes a multi-word nominal under its first word
+func (index *grammarIndex) addPhrase(words []string, production *Production, nominal string) {
+	phrases := index.phrases[words[0]]
+	for phraseIndex := range phrases {
+		if equalWords(phrases[phraseIndex].words, words) {
+			phrases[phraseIndex].nominals = appendNominal(phrases[phraseIndex].nominals, production, nominal)
+			return
+		}
+	}
+	index.phrases[words[0]] = append(phrases, indexedPhrase{words: words, nominals: []indexedNominal{{production: production, nominal: nominal}}})
+}
+
+// appendNominal adds a production's nominal to the matches, unless the production already matches the same words
+func appendNominal(matches []indexedNominal, production *Production, nominal string) []indexedNominal {
+	if len(matches) > 0 && matches[len(matches)-1].production == production {
+		return matches
+	}
+	return append(matches, indexedNominal{production: production, nominal: nominal})
+}
+
+// terminalLookup returns a list of Parses for a given word
+// Only single-word nominals match, multi-word nominals are found by phraseLookup.
+// Productions whose features clash with the nominal's features are left out.
+func (index *grammarIndex) terminalLookup(word string) []Parse {
+	return nominalParses(index.terminals[word])
+}
+
+// nominalParses builds a terminal node for each match whose features do not clash
+func nominalParses(matches []indexedNominal) []Parse {
+	matchingParses := []Parse{}
+	for _, match := range matches {
+		if node, ok := newTerminalParse(match.production, match.nominal); ok {
 			matchingParses = append(matchingParses, node)
 		}
 	}
 	return matchingParses
 }
 
+// matchingPhrases returns the multi-word nominals that start at the first of the words
+func (index *grammarIndex) matchingPhrases(words []string) []indexedPhrase {
+	matching := []indexedPhrase{}
+	for _, phrase := range index.phrases[words[0]] {
+		if len(phrase.words) <= len(words) && equalWords(phrase.words, words[:len(phrase.words)]) {
+			matching = append(matching, phrase)
+		}
+	}
+	return matching
+}
+
+// phraseLookup returns the parses of every multi-word nominal starting at the first of the words
+// Each parse is returned with the number of words its nominal covers.
+func (index *grammarIndex) phraseLookup(words []string) ([]Parse, []int) {
+	phraseParses := []Parse{}
+	lengths := []int{}
+	for _, phrase := range index.matchingPhrases(words) {
+		for _, parse := range nominalParses(phrase.nominals) {
+			phraseParses = append(phraseParses, parse)
+			lengths = append(lengths, len(phrase.words))
+		}
+	}
+	return phraseParses, lengths
+}
+
+// equalWords reports whether two lists of words are the same
+func equalWords(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for wordIndex := range a {
+		if a[wordIndex] != b[wordIndex] {
+			return false
+		}
+	}
+	return true
+}
+
+// nominalWords splits a nominal into the words it matches
+// A nominal without any words, such as an empty string, matches itself as a single word.
+func nominalWords(nominal string) []string {
+	words := strings.Fields(nominal)
+	if len(words) == 0 {
+		return []string{nominal}
+	}
+	return words
+}
+
 // nonterminalLookup returns a list of matching productions for a pair of child productions
-func nonterminalLookup(left *Parse, right *Parse, grammar Grammar) []Parse {
+// Productions whose feature constraints the children do not meet are left out.
+func (index *grammarIndex) nonterminalLookup(left *Parse, right *Parse) []Parse {
 	matchingParses := []Parse{}
-	for productionIndex := range grammar {
-		production := &grammar[productionIndex]
-		if production.left == left.production.key && production.right == right.production.key {
-			node := Parse{production: production, left: left, right: right}
+	components := componentKeys{left: left.production.key, right: right.production.key}
+	for _, production := range index.nonterminals[components] {
+		if node, ok := newNonterminalParse(production, left, right); ok {
 			matchingParses = append(matchingParses, node)
 		}
 	}
 	return matchingParses
 }
+
+// GrammarError is returned by Grammar.Validate, listing every problem found
+type GrammarError struct {
+	Problems []string
+}
+
+func (e *GrammarError) Error() string {
+	return "gocky: invalid grammar: " + strings.Join(e.Problems, "; ")
+}
+
+// Validate checks a grammar for mistakes that parsing would silently accept
+// It reports productions without a key, non-terminal productions missing a component key or using a key no production produces,
+// empty nominals, weighted terminal productions without one probability per nominal, and probabilities outside 0 to 1.
+// A grammar with problems returns a *GrammarError.
+func (g Grammar) Validate() error {
+	problems := []string{}
+	produced := map[string]bool{}
+	for _, production := range g {
+		produced[production.key] = true
+	}
+	for productionIndex, production := range g {
+		if len(production.key) == 0 {
+			problems = append(problems, fmt.Sprintf("production %d has no key", productionIndex))
+		}
+		if len(production.left) > 0 || len(production.right) > 0 {
+			if len(production.left) == 0 || len(production.right) == 0 {
+				problems = append(problems, fmt.Sprintf("production %d (%s) needs a left and a right key", productionIndex, production.key))
+			}
+			for componentIndex, component := range []string{production.left, production.right} {
+				if componentIndex == 1 && component == production.left {
+					continue
+				}
+				if len(component) > 0 && !produced[component] {
+					problems = append(problems, fmt.Sprintf("production %d (%s) uses %q, which no production produces", productionIndex, production.key, component))
+				}
+			}
+		}
+		for _, nominal := range production.nominals {
+			if len(strings.TrimSpace(nominal)) == 0 {
+				problems = append(problems, fmt.Sprintf("production %d (%s) has an empty nominal", productionIndex, production.key))
+				break
+			}
+		}
+		if !production.weighted {
+			continue
+		}
+		probabilities := []float64{production.probability}
+		if len(production.nominals) > 0 {
+			probabilities = production.nominalProbabilities
+			if len(probabilities) != len(production.nominals) {
+				problems = append(problems, fmt.Sprintf("production %d (%s) has %d probabilities for %d nominals", productionIndex, production.key, len(probabilities), len(production.nominals)))
+			}
+		}
+		for _, probability := range probabilities {
+			if !(probability >= 0 && probability <= 1) {
+				problems = append(problems, fmt.Sprintf("production %d (%s) has probability %v, outside 0 to 1", productionIndex, production.key, probability))
+				break
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return &GrammarError{Problems: problems}
+	}
+	return nil
+}
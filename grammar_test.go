@@ -1,6 +1,11 @@
 package gocky
 
-import "testing"
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
 
 func TestTerminalLookup(t *testing.T) {
 	type test struct {
@@ -26,7 +31,7 @@ func TestTerminalLookup(t *testing.T) {
 	}
 
 	for _, testCase := range tests {
-		actualMatches := terminalLookup(testCase.lookup, testCase.grammar)
+		actualMatches := indexGrammar(testCase.grammar).terminalLookup(testCase.lookup)
 
 		if len(actualMatches) != len(testCase.expectedProductions) {
 			t.Fatalf("(Test \"%s\"), num matches expected %d, got %d", testCase.name, len(testCase.expectedProductions), len(actualMatches))
@@ -101,7 +106,7 @@ func TestNonterminalLookup(t *testing.T) {
 	}
 
 	for _, testCase := range tests {
-		actualMatches := nonterminalLookup(&testCase.left, &testCase.right, testCase.grammar)
+		actualMatches := indexGrammar(testCase.grammar).nonterminalLookup(&testCase.left, &testCase.right)
 		if len(actualMatches) != len(testCase.expectedProductions) {
 			t.Fatalf("(Test \"%s\"), num matches expected %d, got %d", testCase.name, len(testCase.expectedProductions), len(actualMatches))
 		}
@@ -123,3 +128,133 @@ func TestNonterminalLookup(t *testing.T) {
 		}
 	}
 }
+
+func TestWeightedProductions(t *testing.T) {
+	unweightedTerminal := TerminalProduction("N", []string{"dog", "cat"})
+	unweightedNonterminal := NonterminalProduction("NP", "DT", "N")
+	weightedTerminal := WeightedTerminalProduction("N", []string{"dog", "cat"}, []float64{0.25, 0.75})
+	weightedNonterminal := WeightedNonterminalProduction("NP", "DT", "N", 0.5)
+
+	if unweightedTerminal.Weighted() || unweightedNonterminal.Weighted() {
+		t.Errorf("Unweighted productions should not report weights")
+	}
+	if !weightedTerminal.Weighted() || !weightedNonterminal.Weighted() {
+		t.Errorf("Weighted productions should report weights")
+	}
+	if unweightedNonterminal.Probability() != 1 || unweightedTerminal.NominalProbability("cat") != 1 {
+		t.Errorf("Unweighted productions should have a probability of 1")
+	}
+	if weightedNonterminal.Probability() != 0.5 {
+		t.Errorf("Expected probability 0.5, got %f", weightedNonterminal.Probability())
+	}
+	if weightedTerminal.NominalProbability("cat") != 0.75 {
+		t.Errorf("Expected probability 0.75, got %f", weightedTerminal.NominalProbability("cat"))
+	}
+	if weightedTerminal.NominalProbability("bird") != 0 || unweightedTerminal.NominalProbability("bird") != 0 {
+		t.Errorf("Missing nominals should have a probability of 0")
+	}
+	shortTerminal := WeightedTerminalProduction("N", []string{"dog", "cat"}, []float64{1})
+	if shortTerminal.NominalProbability("cat") != 0 || shortTerminal.String() != "N -> \"dog\" [1] | \"cat\" [0]" {
+		t.Errorf("A nominal without a probability should have a probability of 0, got %s", shortTerminal.String())
+	}
+}
+
+func TestProductionAccessors(t *testing.T) {
+	terminal := TerminalProduction("N", []string{"dog", "cat"})
+	nonterminal := NonterminalProduction("NP", "DT", "N")
+
+	if terminal.Key() != "N" || terminal.Left() != "" || terminal.Right() != "" {
+		t.Errorf("Unexpected terminal production %s", terminal.String())
+	}
+	if nominals := terminal.Nominals(); len(nominals) != 2 || nominals[1] != "cat" {
+		t.Errorf("Expected nominals [dog cat], got %v", nominals)
+	}
+	if nonterminal.Key() != "NP" || nonterminal.Left() != "DT" || nonterminal.Right() != "N" || len(nonterminal.Nominals()) != 0 {
+		t.Errorf("Unexpected non-terminal production %s", nonterminal.String())
+	}
+}
+
+func TestValidate(t *testing.T) {
+	type test struct {
+		name             string
+		grammar          Grammar
+		expectedProblems []string
+	}
+
+	testCases := []test{
+		{name: "book flight", grammar: bookFlight()},
+		{name: "panda", grammar: panda()},
+		{name: "empty", grammar: Grammar{}},
+		{
+			name: "weighted",
+			grammar: Grammar{
+				WeightedTerminalProduction("N", []string{"dog", "cat"}, []float64{0.5, 0.5}),
+				WeightedNonterminalProduction("N", "N", "N", 1),
+			},
+		},
+		{
+			name:             "undefined key",
+			grammar:          bigDog(),
+			expectedProblems: []string{"production 5 (N) uses \"NP\", which no production produces", "production 6 (N) uses \"NP\", which no production produces"},
+		},
+		{
+			name:             "repeated undefined key",
+			grammar:          Grammar{NonterminalProduction("NP", "N", "N")},
+			expectedProblems: []string{"production 0 (NP) uses \"N\", which no production produces"},
+		},
+		{
+			name:             "empty nominal",
+			grammar:          Grammar{TerminalProduction("N", []string{"dog", " "})},
+			expectedProblems: []string{"production 0 (N) has an empty nominal"},
+		},
+		{
+			name: "missing keys",
+			grammar: Grammar{
+				TerminalProduction("", []string{"dog"}),
+				NonterminalProduction("N", "", "N"),
+			},
+			expectedProblems: []string{"production 0 has no key", "production 1 (N) needs a left and a right key"},
+		},
+		{
+			name: "probabilities",
+			grammar: Grammar{
+				WeightedTerminalProduction("N", []string{"dog", "cat"}, []float64{0.5, 1.5}),
+				WeightedNonterminalProduction("N", "N", "N", -1),
+				WeightedNonterminalProduction("N", "N", "N", math.NaN()),
+			},
+			expectedProblems: []string{
+				"production 0 (N) has probability 1.5, outside 0 to 1",
+				"production 1 (N) has probability -1, outside 0 to 1",
+				"production 2 (N) has probability NaN, outside 0 to 1",
+			},
+		},
+		{
+			name: "probability count",
+			grammar: Grammar{
+				WeightedTerminalProduction("N", []string{"dog", "cat"}, []float64{1}),
+				WeightedTerminalProduction("V", []string{"barks"}, []float64{0.5, 0.5}),
+			},
+			expectedProblems: []string{
+				"production 0 (N) has 1 probabilities for 2 nominals",
+				"production 1 (V) has 2 probabilities for 1 nominals",
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		err := testCase.grammar.Validate()
+		if len(testCase.expectedProblems) == 0 {
+			if err != nil {
+				t.Errorf("(Test \"%s\"), unexpected error %v", testCase.name, err)
+			}
+			continue
+		}
+		var grammarErr *GrammarError
+		if !errors.As(err, &grammarErr) {
+			t.Fatalf("(Test \"%s\"), expected a GrammarError, got %v", testCase.name, err)
+		}
+		if !reflect.DeepEqual(grammarErr.Problems, testCase.expectedProblems) {
+			t.Errorf("(Test \"%s\"), expected problems %v, got %v", testCase.name, testCase.expectedProblems, grammarErr.Problems)
+		}
+	}
+}
@@ -0,0 +1,444 @@
+package gocky
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ReadGrammar reads a grammar written in the gocky grammar format
+//
+// Each line holds one or more productions for a key, separated by "|".
+// Non-terminal productions name two component keys, and terminal productions list quoted nominals.
+// A probability in square brackets may follow each production, and "#" starts a comment.
+//
+//	# A tiny grammar
+//	S  -> NP V [0.8] | N V [0.2]
+//	NP -> DT N
+//	DT -> "the" | "a"
+//	N  -> "dog" [0.7] | "cat" [0.3]
+//	V  -> "barks"
+//
+// The nominals on one line become a single terminal production, and each pair of keys becomes its own non-terminal production.
+// A line cannot mix nominals with component keys, and either every production on a line has a probability or none do.
+//
+// Grammar files read by LoadGrammar can also import other grammar files, as described there.
+// ReadGrammar has no file to resolve imports against, so it returns an error for them.
+func ReadGrammar(reader io.Reader) (Grammar, error) {
+	grammar, imports, err := readGrammarFile(reader)
+	if err != nil {
+		return nil, err
+	}
+	if len(imports) > 0 {
+		return nil, fmt.Errorf("gocky: grammar line %d: imports can only be read by LoadGrammar", imports[0].line)
+	}
+	return grammar, nil
+}
+
+// LoadGrammar reads a grammar file written in the format described by ReadGrammar
+//
+// A grammar file can import the productions of other grammar files, optionally namespacing their keys:
+//
+//	import "numbers.gky" as numbers
+//	import "common.gky"
+//	PRICE -> numbers.NUMBER CURRENCY
+//
+// Import paths are relative to the importing file. The file's own productions come first, then each import in order.
+// An import with "as" is namespaced with Grammar.Namespace, so its keys cannot collide with those of other files.
+// A file imported more than once under the same namespace, such as a module shared by two other imports, is only loaded the first time.
+// A key produced by more than one of the files is an error, as is a file that imports itself.
+func LoadGrammar(path string) (Grammar, error) {
+	loader := &grammarLoader{loading: map[string]bool{}, loaded: map[grammarModule]bool{}}
+	if err := loader.load(path, ""); err != nil {
+		return nil, err
+	}
+	if conflicts := mergeConflicts(loader.segments); len(conflicts) > 0 {
+		descriptions := []string{}
+		for _, conflict := range conflicts {
+			conflictSources := []string{}
+			for _, segment := range conflict.Grammars {
+				conflictSources = append(conflictSources, loader.sources[segment])
+			}
+			descriptions = append(descriptions, fmt.Sprintf("%q is produced by %s", conflict.Key, strings.Join(conflictSources, " and ")))
+		}
+		return nil, fmt.Errorf("gocky: grammar %s: %s", path, strings.Join(descriptions, "; "))
+	}
+	merged, _ := Merge(loader.segments...)
+	return merged, nil
+}
+
+// GrammarImport is an import line of a grammar file, as described by LoadGrammar
+// Namespace is empty for an import without "as".
+type GrammarImport struct {
+	Path      string
+	Namespace string
+}
+
+// grammarImport is an import line of a grammar file, along with its line number
+type grammarImport struct {
+	GrammarImport
+	line int
+}
+
+// grammarModule is a grammar file loaded under a namespace, which is empty for a file that is not namespaced
+type grammarModule struct {
+	path      string
+	namespace string
+}
+
+// grammarLoader loads a grammar file and its imports for LoadGrammar
+// Each file's own productions become a segment, and sources holds the path each segment was read from.
+type grammarLoader struct {
+	loading  map[string]bool
+	loaded   map[grammarModule]bool
+	segments []Grammar
+	sources  []string
+}
+
+// load reads a grammar file and then its imports, namespacing their productions
+// loading holds the files being imported further up the chain, and loaded the modules already read.
+func (l *grammarLoader) load(path string, namespace string) error {
+	absolutePath, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if l.loading[absolutePath] {
+		return fmt.Errorf("gocky: grammar %s imports itself", path)
+	}
+	module := grammarModule{path: absolutePath, namespace: namespace}
+	if l.loaded[module] {
+		return nil
+	}
+	l.loaded[module] = true
+	l.loading[absolutePath] = true
+	defer delete(l.loading, absolutePath)
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	grammar, imports, err := readGrammarFile(file)
+	file.Close()
+	if err != nil {
+		return err
+	}
+	if len(namespace) > 0 {
+		grammar = grammar.Namespace(namespace)
+	}
+	l.segments = append(l.segments, grammar)
+	l.sources = append(l.sources, path)
+
+	for _, importLine := range imports {
+		importPath := importLine.Path
+		if !filepath.IsAbs(importPath) {
+			importPath = filepath.Join(filepath.Dir(path), importPath)
+		}
+		importNamespace := namespace
+		if len(importLine.Namespace) > 0 {
+			importNamespace = joinNamespaces(namespace, importLine.Namespace)
+		}
+		if err := l.load(importPath, importNamespace); err != nil {
+			return fmt.Errorf("gocky: grammar line %d: importing %q: %w", importLine.line, importLine.Path, err)
+		}
+	}
+	return nil
+}
+
+// joinNamespaces nests the inner namespace within the outer one, as namespacing a grammar twice would
+func joinNamespaces(outer string, inner string) string {
+	if len(outer) == 0 {
+		return inner
+	}
+	return outer + NamespaceSeparator + inner
+}
+
+// readGrammarFile reads the productions and imports of a grammar file
+func readGrammarFile(reader io.Reader) (Grammar, []grammarImport, error) {
+	grammar := Grammar{}
+	imports := []grammarImport{}
+	scanner := bufio.NewScanner(reader)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		tokens, err := tokenizeGrammarLine(scanner.Text())
+		if err != nil {
+			return nil, nil, fmt.Errorf("gocky: grammar line %d: %w", lineNumber, err)
+		}
+		if isImportLine(tokens) {
+			importLine, err := readImportLine(tokens)
+			if err != nil {
+				return nil, nil, fmt.Errorf("gocky: grammar line %d: %w", lineNumber, err)
+			}
+			importLine.line = lineNumber
+			imports = append(imports, importLine)
+			continue
+		}
+		productions, err := readGrammarLine(tokens)
+		if err != nil {
+			return nil, nil, fmt.Errorf("gocky: grammar line %d: %w", lineNumber, err)
+		}
+		grammar = append(grammar, productions...)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	return grammar, imports, nil
+}
+
+// isImportLine reports whether the line is an import rather than productions for a key named "import"
+func isImportLine(tokens []grammarToken) bool {
+	return len(tokens) >= 2 && !tokens[0].quoted && tokens[0].text == "import" && tokens[1].text != "->"
+}
+
+// readImportLine reads an import line: import "path" or import "path" as namespace
+func readImportLine(tokens []grammarToken) (grammarImport, error) {
+	if !tokens[1].quoted || len(tokens[1].text) == 0 {
+		return grammarImport{}, fmt.Errorf("expected a quoted path after import")
+	}
+	parsed := grammarImport{GrammarImport: GrammarImport{Path: tokens[1].text}}
+	switch {
+	case len(tokens) == 2:
+	case len(tokens) == 4 && !tokens[2].quoted && tokens[2].text == "as" && !tokens[3].quoted && isGrammarKey(tokens[3].text):
+		parsed.Namespace = tokens[3].text
+	default:
+		return grammarImport{}, fmt.Errorf("expected import \"path\" or import \"path\" as namespace")
+	}
+	return parsed, nil
+}
+
+// ReadGrammarFile reads the productions and import lines of a grammar file, without loading the imports
+// Tools that edit grammar files can change the file's own productions and write them back with WriteGrammarFile,
+// rather than writing out the imported productions that LoadGrammar includes.
+func ReadGrammarFile(reader io.Reader) (Grammar, []GrammarImport, error) {
+	grammar, imports, err := readGrammarFile(reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	importLines := make([]GrammarImport, 0, len(imports))
+	for _, importLine := range imports {
+		importLines = append(importLines, importLine.GrammarImport)
+	}
+	return grammar, importLines, nil
+}
+
+// WriteGrammarFile writes the import lines and then the grammar, in the format described by LoadGrammar
+func WriteGrammarFile(writer io.Writer, imports []GrammarImport, grammar Grammar) error {
+	for _, importLine := range imports {
+		line := "import " + strconv.Quote(importLine.Path)
+		if len(importLine.Namespace) > 0 {
+			line += " as " + importLine.Namespace
+		}
+		if _, err := fmt.Fprintln(writer, line); err != nil {
+			return err
+		}
+	}
+	return WriteGrammar(writer, grammar)
+}
+
+// WriteGrammar writes the grammar in the format described by ReadGrammar, one production per line
+// Features and semantic functions cannot be written, and are left out.
+func WriteGrammar(writer io.Writer, grammar Grammar) error {
+	for _, production := range grammar {
+		if _, err := fmt.Fprintln(writer, production.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// String writes the production as a line of the format described by ReadGrammar
+func (p Production) String() string {
+	builder := &strings.Builder{}
+	builder.WriteString(p.key)
+	builder.WriteString(" ->")
+	if len(p.left) > 0 || len(p.right) > 0 {
+		builder.WriteString(" " + p.left + " " + p.right)
+		if p.weighted {
+			builder.WriteString(" [" + strconv.FormatFloat(p.probability, 'g', -1, 64) + "]")
+		}
+		return builder.String()
+	}
+	for nominalIndex, nominal := range p.nominals {
+		if nominalIndex > 0 {
+			builder.WriteString(" |")
+		}
+		builder.WriteString(" " + strconv.Quote(nominal))
+		if p.weighted {
+			builder.WriteString(" [" + strconv.FormatFloat(p.nominalProbabilityAt(nominalIndex), 'g', -1, 64) + "]")
+		}
+	}
+	return builder.String()
+}
+
+// Key returns the key the production produces
+func (p Production) Key() string {
+	return p.key
+}
+
+// Left returns the left component key of a non-terminal production, or an empty string for a terminal production
+func (p Production) Left() string {
+	return p.left
+}
+
+// Right returns the right component key of a non-terminal production, or an empty string for a terminal production
+func (p Production) Right() string {
+	return p.right
+}
+
+// Nominals returns the nominals of a terminal production
+func (p Production) Nominals() []string {
+	return append([]string{}, p.nominals...)
+}
+
+// grammarToken is a piece of a grammar line
+type grammarToken struct {
+	text   string
+	quoted bool
+}
+
+// readGrammarLine reads the productions on one line of a grammar
+func readGrammarLine(tokens []grammarToken) ([]Production, error) {
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+	if len(tokens) < 2 || tokens[0].quoted || tokens[1].quoted || tokens[1].text != "->" {
+		return nil, fmt.Errorf("expected a key followed by \"->\"")
+	}
+	key := tokens[0].text
+	if !isGrammarKey(key) {
+		return nil, fmt.Errorf("invalid key %q", key)
+	}
+
+	nonterminals := []Production{}
+	nominals := []string{}
+	probabilities := []float64{}
+	weightedCount := 0
+	alternatives := splitAlternatives(tokens[2:])
+	for _, alternative := range alternatives {
+		probability, weighted, symbols, err := alternativeProbability(alternative)
+		if err != nil {
+			return nil, err
+		}
+		if weighted {
+			weightedCount++
+		}
+		switch {
+		case len(symbols) == 1 && symbols[0].quoted:
+			nominals = append(nominals, symbols[0].text)
+			probabilities = append(probabilities, probability)
+		case len(symbols) == 2 && !symbols[0].quoted && !symbols[1].quoted && isGrammarKey(symbols[0].text) && isGrammarKey(symbols[1].text):
+			production := NonterminalProduction(key, symbols[0].text, symbols[1].text)
+			if weighted {
+				production = WeightedNonterminalProduction(key, symbols[0].text, symbols[1].text, probability)
+			}
+			nonterminals = append(nonterminals, production)
+		case len(symbols) == 0 && len(alternatives) == 1:
+		default:
+			return nil, fmt.Errorf("expected a quoted nominal or two keys for %q", key)
+		}
+	}
+	if weightedCount != 0 && weightedCount != len(alternatives) {
+		return nil, fmt.Errorf("either every production for %q on a line has a probability or none do", key)
+	}
+	if len(nominals) > 0 && len(nonterminals) > 0 {
+		return nil, fmt.Errorf("%q mixes nominals with component keys on one line", key)
+	}
+	if len(nonterminals) > 0 {
+		return nonterminals, nil
+	}
+	if weightedCount > 0 {
+		return []Production{WeightedTerminalProduction(key, nominals, probabilities)}, nil
+	}
+	return []Production{TerminalProduction(key, nominals)}, nil
+}
+
+// splitAlternatives splits the right hand side of a line at each "|"
+func splitAlternatives(tokens []grammarToken) [][]grammarToken {
+	alternatives := [][]grammarToken{{}}
+	for _, token := range tokens {
+		if !token.quoted && token.text == "|" {
+			alternatives = append(alternatives, []grammarToken{})
+			continue
+		}
+		alternatives[len(alternatives)-1] = append(alternatives[len(alternatives)-1], token)
+	}
+	return alternatives
+}
+
+// alternativeProbability separates a trailing "[probability]" from the symbols of an alternative
+func alternativeProbability(alternative []grammarToken) (float64, bool, []grammarToken, error) {
+	if len(alternative) == 0 {
+		return 0, false, alternative, nil
+	}
+	last := alternative[len(alternative)-1]
+	if last.quoted || !strings.HasPrefix(last.text, "[") {
+		return 0, false, alternative, nil
+	}
+	probability, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimPrefix(last.text, "["), "]"), 64)
+	if err != nil {
+		return 0, false, nil, fmt.Errorf("invalid probability %s", last.text)
+	}
+	return probability, true, alternative[:len(alternative)-1], nil
+}
+
+// isGrammarKey reports whether a bare token can be used as a key
+func isGrammarKey(text string) bool {
+	return len(text) > 0 && text != "->" && text != "|" && !strings.HasPrefix(text, "[")
+}
+
+// tokenizeGrammarLine splits a grammar line into keys, quoted nominals, arrows, bars and probabilities
+func tokenizeGrammarLine(line string) ([]grammarToken, error) {
+	tokens := []grammarToken{}
+	runes := []rune(line)
+	for position := 0; position < len(runes); {
+		character := runes[position]
+		switch {
+		case unicode.IsSpace(character):
+			position++
+		case character == '#':
+			return tokens, nil
+		case character == '|':
+			tokens = append(tokens, grammarToken{text: "|"})
+			position++
+		case character == '"':
+			end := position + 1
+			for end < len(runes) && runes[end] != '"' {
+				if runes[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(runes) {
+				return nil, fmt.Errorf("unterminated nominal")
+			}
+			nominal, err := strconv.Unquote(string(runes[position : end+1]))
+			if err != nil {
+				return nil, fmt.Errorf("invalid nominal %s", string(runes[position:end+1]))
+			}
+			tokens = append(tokens, grammarToken{text: nominal, quoted: true})
+			position = end + 1
+		case character == '[':
+			end := position
+			for end < len(runes) && runes[end] != ']' {
+				end++
+			}
+			if end >= len(runes) {
+				return nil, fmt.Errorf("unterminated probability")
+			}
+			tokens = append(tokens, grammarToken{text: string(runes[position : end+1])})
+			position = end + 1
+		default:
+			start := position
+			for position < len(runes) && !unicode.IsSpace(runes[position]) && !strings.ContainsRune("#|\"[", runes[position]) {
+				position++
+			}
+			tokens = append(tokens, grammarToken{text: string(runes[start:position])})
+		}
+	}
+	return tokens, nil
+}
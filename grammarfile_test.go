@@ -0,0 +1,218 @@
+package gocky
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// compareGrammars tests whether two grammars have the same productions in the same order
+func compareGrammars(t *testing.T, name string, expected Grammar, actual Grammar) {
+	expectedLines, actualLines := []string{}, []string{}
+	for _, production := range expected {
+		expectedLines = append(expectedLines, production.String())
+	}
+	for _, production := range actual {
+		actualLines = append(actualLines, production.String())
+	}
+	if !reflect.DeepEqual(expectedLines, actualLines) {
+		t.Errorf("%s expected grammar %q but got %q", name, expectedLines, actualLines)
+	}
+}
+
+func TestReadGrammar(t *testing.T) {
+	text := `
+# The book flight grammar
+DT -> "the" | "that" | "a"
+N  -> "book" | "flight"   # nouns
+V  -> "book"
+JJ ->
+NP -> DT N
+VP -> V NP
+`
+	grammar, err := ReadGrammar(strings.NewReader(text))
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	compareGrammars(t, "book flight", bookFlight(), grammar)
+}
+
+func TestReadGrammarWeighted(t *testing.T) {
+	text := `
+S -> NP V [0.75] | N V [0.25]
+N -> "dog" [0.5] | "New York" [0.5]
+`
+	grammar, err := ReadGrammar(strings.NewReader(text))
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	expectedGrammar := Grammar{
+		WeightedNonterminalProduction("S", "NP", "V", 0.75),
+		WeightedNonterminalProduction("S", "N", "V", 0.25),
+		WeightedTerminalProduction("N", []string{"dog", "New York"}, []float64{0.5, 0.5}),
+	}
+	compareGrammars(t, "weighted", expectedGrammar, grammar)
+	if !grammar[2].Weighted() || grammar[2].NominalProbability("New York") != 0.5 {
+		t.Errorf("Expected \"New York\" to have probability 0.5, got %f", grammar[2].NominalProbability("New York"))
+	}
+}
+
+func TestReadGrammarErrors(t *testing.T) {
+	testCases := []string{
+		"S NP V",
+		"S -> NP",
+		"S -> NP V W",
+		"S -> NP V | \"dog\"",
+		"S -> NP V [0.5] | N V",
+		"N -> \"dog",
+		"N -> \"dog\" [0.5",
+		"N -> \"dog\" [half]",
+		"-> -> NP V",
+		"import \"numbers.gky\"",
+		"import numbers.gky",
+		"import \"numbers.gky\" as",
+		"import \"numbers.gky\" like numbers",
+	}
+	for _, testCase := range testCases {
+		if _, err := ReadGrammar(strings.NewReader(testCase)); err == nil {
+			t.Errorf("(Test %q), expected an error", testCase)
+		}
+	}
+}
+
+func TestWriteGrammar(t *testing.T) {
+	grammar := append(panda(),
+		WeightedTerminalProduction("Q", []string{"say \"hi\""}, []float64{0.25}),
+		WeightedNonterminalProduction("S8", "Q", "Q", 1),
+	)
+	output := &bytes.Buffer{}
+	if err := WriteGrammar(output, grammar); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if !strings.Contains(output.String(), "N -> \"panda\" | \"shoots\" | \"leaves\"\n") {
+		t.Errorf("Expected the noun production to be written on one line, got %s", output.String())
+	}
+	readGrammar, err := ReadGrammar(output)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	compareGrammars(t, "round trip", grammar, readGrammar)
+}
+
+func TestReadWriteGrammarFile(t *testing.T) {
+	input := "import \"numbers.gky\" as numbers\n# units\nimport \"units.gky\"\nMEASURE -> numbers.NUMBER UNIT\n"
+	grammar, imports, err := ReadGrammarFile(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	expectedImports := []GrammarImport{{Path: "numbers.gky", Namespace: "numbers"}, {Path: "units.gky"}}
+	if !reflect.DeepEqual(imports, expectedImports) {
+		t.Errorf("Expected imports %v, got %v", expectedImports, imports)
+	}
+	compareGrammars(t, "own productions", Grammar{NonterminalProduction("MEASURE", "numbers.NUMBER", "UNIT")}, grammar)
+
+	output := &bytes.Buffer{}
+	if err := WriteGrammarFile(output, imports, grammar); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	expectedOutput := "import \"numbers.gky\" as numbers\nimport \"units.gky\"\nMEASURE -> numbers.NUMBER UNIT\n"
+	if output.String() != expectedOutput {
+		t.Errorf("Expected %q, got %q", expectedOutput, output.String())
+	}
+}
+
+// writeGrammarFile writes a grammar file, creating the directories it is in
+func writeGrammarFile(t *testing.T, path string, grammar string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if err := os.WriteFile(path, []byte(grammar), 0o644); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+}
+
+func TestLoadGrammarImports(t *testing.T) {
+	files := map[string]string{
+		"main.gky":            "import \"modules/numbers.gky\" as numbers\nimport \"units.gky\"\nimport -> numbers.NUMBER UNIT\n",
+		"units.gky":           "UNIT -> \"kg\" | \"m\"\n",
+		"modules/numbers.gky": "import \"digits.gky\" as digits\nNUMBER -> digits.DIGIT digits.DIGIT\nNUMBER -> \"ten\"\n",
+		"modules/digits.gky":  "DIGIT -> \"1\" | \"2\"\n",
+		"cycle.gky":           "import \"cycle2.gky\" as other\nS -> other.S other.S\n",
+		"cycle2.gky":          "import \"cycle.gky\"\n",
+		"conflict.gky":        "import \"units.gky\"\nUNIT -> \"g\"\n",
+		"missing.gky":         "import \"nowhere.gky\"\n",
+		"broken.gky":          "import \"modules/broken.gky\"\n",
+		"modules/broken.gky":  "\nN -> \"dog\n",
+		"twice.gky":           "import \"units.gky\" as a\nimport \"units.gky\" as b\n",
+		"shared.gky":          "import \"dates.gky\"\nimport \"addr.gky\"\nimport \"./common.gky\"\nS -> DATE ADDR\n",
+		"dates.gky":           "import \"common.gky\"\nDATE -> NUM MONTH\nMONTH -> \"may\"\n",
+		"addr.gky":            "import \"common.gky\"\nimport \"common.gky\" as c\nADDR -> NUM c.STREET\n",
+		"common.gky":          "NUM -> \"1\" | \"2\"\nSTREET -> \"elm\"\n",
+	}
+	directory := t.TempDir()
+	for name, grammar := range files {
+		writeGrammarFile(t, filepath.Join(directory, name), grammar)
+	}
+
+	grammar, err := LoadGrammar(filepath.Join(directory, "main.gky"))
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	expected := Grammar{
+		NonterminalProduction("import", "numbers.NUMBER", "UNIT"),
+		NonterminalProduction("numbers.NUMBER", "numbers.digits.DIGIT", "numbers.digits.DIGIT"),
+		TerminalProduction("numbers.NUMBER", []string{"ten"}),
+		TerminalProduction("numbers.digits.DIGIT", []string{"1", "2"}),
+		TerminalProduction("UNIT", []string{"kg", "m"}),
+	}
+	compareGrammars(t, "imports", expected, grammar)
+	if err := grammar.Validate(); err != nil {
+		t.Errorf("Expected the imported grammar to be valid, got %v", err)
+	}
+	if parses := MatchingParses([]string{"1", "2", "kg"}, grammar, []string{"import"}); len(parses) != 1 {
+		t.Errorf("Expected 1 parse across the imported grammars, got %d", len(parses))
+	}
+
+	twice, err := LoadGrammar(filepath.Join(directory, "twice.gky"))
+	if err != nil || !reflect.DeepEqual(twice.Keys(), []string{"a.UNIT", "b.UNIT"}) {
+		t.Errorf("Expected a module imported under two namespaces, got %v %v", twice.Keys(), err)
+	}
+
+	shared, err := LoadGrammar(filepath.Join(directory, "shared.gky"))
+	if err != nil {
+		t.Fatalf("Expected a module shared by two imports to load once, got %v", err)
+	}
+	expectedShared := []string{"S", "DATE", "MONTH", "NUM", "STREET", "ADDR", "c.NUM", "c.STREET"}
+	if !reflect.DeepEqual(shared.Keys(), expectedShared) || len(shared) != 8 {
+		t.Errorf("Expected keys %v in 8 productions, got %v in %d", expectedShared, shared.Keys(), len(shared))
+	}
+	if parses := MatchingParses([]string{"1", "may", "2", "elm"}, shared, []string{"S"}); len(parses) != 1 {
+		t.Errorf("Expected 1 parse across the shared modules, got %d", len(parses))
+	}
+
+	type test struct {
+		file          string
+		expectedError string
+	}
+
+	testCases := []test{
+		{file: "cycle.gky", expectedError: "imports itself"},
+		{file: "conflict.gky", expectedError: "\"UNIT\" is produced by"},
+		{file: "missing.gky", expectedError: "gocky: grammar line 1: importing \"nowhere.gky\""},
+		{file: "broken.gky", expectedError: "gocky: grammar line 1: importing \"modules/broken.gky\": gocky: grammar line 2: unterminated nominal"},
+	}
+
+	for _, testCase := range testCases {
+		_, err := LoadGrammar(filepath.Join(directory, testCase.file))
+		if err == nil || !strings.Contains(err.Error(), testCase.expectedError) {
+			t.Errorf("(Test \"%s\"), expected an error containing %q, got %v", testCase.file, testCase.expectedError, err)
+		}
+	}
+
+	if _, err := ReadGrammar(strings.NewReader("import \"units.gky\"\n")); err == nil || !strings.Contains(err.Error(), "LoadGrammar") {
+		t.Errorf("Expected ReadGrammar to refuse imports, got %v", err)
+	}
+}
@@ -0,0 +1,57 @@
+package gocky
+
+import "fmt"
+
+// ParseOptions bounds the work a single parse is allowed to do, and how that work is shared out
+// A zero value for any limit means that limit is not enforced
+type ParseOptions struct {
+	// MaxWords is the longest sentence that will be parsed
+	MaxWords int
+	// MaxCellSize is the largest number of parses a single chart cell may hold
+	MaxCellSize int
+	// MaxParseNodes is the largest number of parses the whole chart may hold
+	MaxParseNodes int
+	// Workers is the number of goroutines used to fill each diagonal of the chart
+	// Zero or one fills the chart serially
+	Workers int
+	// Deduplicate drops parses that are structurally equal to an earlier parse, as Parse.Equal describes
+	// Duplicates come from redundant grammar entries, such as a nominal listed under two productions with the same key.
+	Deduplicate bool
+	// Tracer is told about each step of the parse, when it is not nil
+	Tracer Tracer
+}
+
+// Limit names one of the limits in ParseOptions
+type Limit int
+
+const (
+	// LimitWords is reported when a sentence is longer than ParseOptions.MaxWords
+	LimitWords Limit = iota + 1
+	// LimitCellSize is reported when a chart cell grows past ParseOptions.MaxCellSize
+	LimitCellSize
+	// LimitParseNodes is reported when the chart grows past ParseOptions.MaxParseNodes
+	LimitParseNodes
+)
+
+// String returns the name of the limit
+func (l Limit) String() string {
+	switch l {
+	case LimitWords:
+		return "max words"
+	case LimitCellSize:
+		return "max cell size"
+	case LimitParseNodes:
+		return "max parse nodes"
+	}
+	return fmt.Sprintf("Limit(%d)", int(l))
+}
+
+// LimitError is returned when a parse is abandoned because it exceeded one of its ParseOptions
+type LimitError struct {
+	Limit Limit
+	Max   int
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("gocky: parse exceeded %s (%d)", e.Limit, e.Max)
+}
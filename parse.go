@@ -1,5 +1,11 @@
 package gocky
 
+import (
+	"hash"
+	"hash/fnv"
+	"strings"
+)
+
 // Parse captures the generated productions or terminal from a generating Production
 // A parsed node can be traced through each production back to all generated terminals
 //
@@ -10,15 +16,67 @@ type Parse struct {
 	left       *Parse
 	right      *Parse
 	terminal   string
+	features   Features
+}
+
+// Key returns the key of the production that generated this node of the parse
+func (p *Parse) Key() string {
+	return p.production.key
+}
+
+// Terminal returns the nominal matched by a terminal node, or an empty string for a non-terminal node
+func (p *Parse) Terminal() string {
+	return p.terminal
+}
+
+// Left returns the left component of a non-terminal node, or nil for a terminal node
+func (p *Parse) Left() *Parse {
+	return p.left
+}
+
+// Right returns the right component of a non-terminal node, or nil for a terminal node
+func (p *Parse) Right() *Parse {
+	return p.right
+}
+
+// Leaf is a terminal node of a parse, along with the span of words it covers
+// Start and End count words from the start of the parse the leaf was taken from, and End is not included in the span.
+type Leaf struct {
+	Node  *Parse
+	Start int
+	End   int
+}
+
+// Words returns the terminals of the parse in order
+func (p *Parse) Words() []string {
+	words := []string{}
+	for _, leaf := range p.Leaves() {
+		words = append(words, nominalWords(leaf.Node.terminal)...)
+	}
+	return words
+}
+
+// Leaves returns the terminal nodes of the parse from left to right, with the span of words each covers
+func (p *Parse) Leaves() []Leaf {
+	leaves := []Leaf{}
+	leafNodes := p.LeafNodes()
+	for leafNodes.Next() {
+		start := 0
+		if len(leaves) > 0 {
+			start = leaves[len(leaves)-1].End
+		}
+		leaves = append(leaves, Leaf{Node: leafNodes.Node(), Start: start, End: start + len(nominalWords(leafNodes.Node().terminal))})
+	}
+	return leaves
 }
 
 // ProductionTerminals returns each substring representing the provided production key.
-// For example, given a production key of "VP", ProductionTerminals will return all terminal combinations that represent a "VP" in this parse.
+// For example, given a production key of "VP", ProductionTerminals will return the words of every "VP" in this parse.
 func (p *Parse) ProductionTerminals(productionKey string) [][]string {
 	matchingSubparses := p.Subparses(productionKey)
 	terminalRepresentations := [][]string{}
 	for _, matchingSubparse := range matchingSubparses {
-		terminalRepresentations = append(terminalRepresentations, nodeTerminals(matchingSubparse)...)
+		terminalRepresentations = append(terminalRepresentations, matchingSubparse.Words())
 	}
 	return terminalRepresentations
 }
@@ -32,48 +90,148 @@ func (p *Parse) Subparses(productionKey string) []*Parse {
 
 // ProductionKeys retrieves the key of every production within the parse
 func (p *Parse) ProductionKeys() []string {
-	productionKeys := []string{p.production.key}
+	productionKeys := []string{}
+	Walk(p, func(node *Parse, depth int) WalkAction {
+		productionKeys = append(productionKeys, node.production.key)
+		return WalkContinue
+	})
+	return productionKeys
+}
+
+// Probability returns the probability of the parse, the product of the probabilities of every production used
+// Unweighted productions count as a probability of 1, so a parse from an unweighted grammar has a probability of 1.
+func (p *Parse) Probability() float64 {
+	if p.left == nil && p.right == nil {
+		return p.production.NominalProbability(p.terminal)
+	}
+	probability := p.production.Probability()
 	if p.left != nil {
-		productionKeys = append(productionKeys, p.left.ProductionKeys()...)
+		probability *= p.left.Probability()
 	}
 	if p.right != nil {
-		productionKeys = append(productionKeys, p.right.ProductionKeys()...)
+		probability *= p.right.Probability()
 	}
-	return productionKeys
+	return probability
 }
 
-// traverseToKey traverses the Parse tree to find component Parses that match the given production key
-func traverseToKey(node *Parse, productionKey string) []*Parse {
-	if node == nil {
-		return []*Parse{}
+// String writes the parse in bracketed format, such as (S (NP (DT the) (N dog)) (V barks))
+func (p *Parse) String() string {
+	builder := &strings.Builder{}
+	writeBracketed(builder, p)
+	return builder.String()
+}
+
+// writeBracketed adds the bracketed format of a node to the builder
+func writeBracketed(builder *strings.Builder, node *Parse) {
+	builder.WriteString("(")
+	builder.WriteString(node.production.key)
+	if node.left == nil && node.right == nil {
+		builder.WriteString(" ")
+		builder.WriteString(node.terminal)
 	}
-	leftMatches := traverseToKey(node.left, productionKey)
-	rightMatches := traverseToKey(node.right, productionKey)
-	matches := append(leftMatches, rightMatches...)
-	if node.production.key == productionKey {
-		matches = append(matches, node)
+	for _, child := range []*Parse{node.left, node.right} {
+		if child != nil {
+			builder.WriteString(" ")
+			writeBracketed(builder, child)
+		}
 	}
-	return matches
+	builder.WriteString(")")
+}
+
+// Equal reports whether two parses have the same structure
+// Parses are structurally equal when every node has the same key, terminal and features, even if they were generated by different productions.
+// Features are compared because parses that differ only in their features can still combine differently, as agreement does.
+func (p *Parse) Equal(other *Parse) bool {
+	if p == nil || other == nil {
+		return p == other
+	}
+	if p == other {
+		return true
+	}
+	return p.production.key == other.production.key &&
+		p.terminal == other.terminal &&
+		p.features.Equal(other.features) &&
+		p.left.Equal(other.left) &&
+		p.right.Equal(other.right)
+}
+
+// Hash returns a hash of the structure of the parse
+// Structurally equal parses always have the same hash.
+func (p *Parse) Hash() uint64 {
+	hasher := fnv.New64a()
+	writeStructure(hasher, p)
+	return hasher.Sum64()
 }
 
-// nodeTerminals collects the terminals for a given Parse
-func nodeTerminals(node *Parse) [][]string {
+// writeStructure writes the keys, terminals and features of a parse to the hasher in pre-order, bracketing each node
+func writeStructure(hasher hash.Hash64, node *Parse) {
 	if node == nil {
-		return [][]string{}
+		hasher.Write([]byte{0})
+		return
 	}
-	if len(node.terminal) > 0 {
-		return [][]string{
-			[]string{node.terminal},
+	hasher.Write([]byte{'('})
+	hasher.Write([]byte(node.production.key))
+	hasher.Write([]byte{0})
+	hasher.Write([]byte(node.terminal))
+	hasher.Write([]byte{0})
+	hasher.Write([]byte(node.features.canonical()))
+	hasher.Write([]byte{0})
+	writeStructure(hasher, node.left)
+	writeStructure(hasher, node.right)
+	hasher.Write([]byte{')'})
+}
+
+// Deduplicate returns the parses with structural duplicates removed, keeping the first of each
+func Deduplicate(parses []Parse) []Parse {
+	distinct := []Parse{}
+	buckets := map[uint64][]int{}
+	for parseIndex := range parses {
+		parse := &parses[parseIndex]
+		hash := parse.Hash()
+		duplicate := false
+		for _, distinctIndex := range buckets[hash] {
+			if distinct[distinctIndex].Equal(parse) {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			buckets[hash] = append(buckets[hash], len(distinct))
+			distinct = append(distinct, *parse)
 		}
 	}
-	leftTerminals := nodeTerminals(node.left)
-	rightTerminals := nodeTerminals(node.right)
-	terminalCombinations := [][]string{}
-	for _, leftTerminal := range leftTerminals {
-		for _, rightTerminal := range rightTerminals {
-			combination := append(leftTerminal, rightTerminal...)
-			terminalCombinations = append(terminalCombinations, combination)
+	return distinct
+}
+
+// traverseToKey traverses the Parse tree to find component Parses that match the given production key
+// Components are searched before the node itself, so deeper matches come first.
+func traverseToKey(node *Parse, productionKey string) []*Parse {
+	matches := []*Parse{}
+	WalkPostOrder(node, func(node *Parse, depth int) WalkAction {
+		if node.production.key == productionKey {
+			matches = append(matches, node)
 		}
+		return WalkContinue
+	})
+	return matches
+}
+
+// Span returns the words covered by a node of the parse, counted from the start of the parse
+// End is not included in the span, and ok is false when the node is not part of the parse.
+func (p *Parse) Span(node *Parse) (start int, end int, ok bool) {
+	spans := map[*Parse][2]int{}
+	for _, leaf := range p.Leaves() {
+		spans[leaf.Node] = [2]int{leaf.Start, leaf.End}
 	}
-	return terminalCombinations
+	WalkPostOrder(p, func(current *Parse, depth int) WalkAction {
+		if current.left != nil && current.right != nil {
+			spans[current] = [2]int{spans[current.left][0], spans[current.right][1]}
+		}
+		if current == node {
+			return WalkStop
+		}
+		return WalkContinue
+	})
+	span, ok := spans[node]
+	return span[0], span[1], ok
 }
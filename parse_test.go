@@ -1,6 +1,7 @@
 package gocky
 
 import (
+	"math"
 	"reflect"
 	"testing"
 )
@@ -112,3 +113,152 @@ func TestParseProductionKeys(t *testing.T) {
 		t.Errorf("Expected production keys %v, got %v", expectedProductionKeys, actualProductionKeys)
 	}
 }
+
+func TestParseProbability(t *testing.T) {
+	grammar := Grammar{
+		WeightedTerminalProduction("DT", []string{"the"}, []float64{1}),
+		WeightedTerminalProduction("N", []string{"dog", "barks"}, []float64{0.8, 0.2}),
+		WeightedTerminalProduction("V", []string{"barks"}, []float64{1}),
+		WeightedNonterminalProduction("NP", "DT", "N", 0.5),
+		WeightedNonterminalProduction("S", "NP", "V", 0.9),
+	}
+	parses := Parses([]string{"the", "dog", "barks"}, grammar)
+	if len(parses) != 1 {
+		t.Fatalf("Expected 1 parse, got %d", len(parses))
+	}
+	expectedProbability := 0.9 * 0.5 * 1 * 0.8 * 1
+	if actualProbability := parses[0].Probability(); math.Abs(expectedProbability-actualProbability) > 1e-12 {
+		t.Errorf("Expected probability %f, got %f", expectedProbability, actualProbability)
+	}
+
+	unweightedParses := Parses([]string{"book", "that", "flight"}, bookFlight())
+	if actualProbability := unweightedParses[0].Probability(); actualProbability != 1 {
+		t.Errorf("Expected unweighted probability 1, got %f", actualProbability)
+	}
+}
+
+func TestParseAccessors(t *testing.T) {
+	parses := Parses([]string{"book", "that", "flight"}, bookFlight())
+	if len(parses) != 1 {
+		t.Fatalf("Expected 1 parse, got %d", len(parses))
+	}
+	root := &parses[0]
+	if root.Key() != "VP" || root.Terminal() != "" {
+		t.Errorf("Expected non-terminal VP, got %s %q", root.Key(), root.Terminal())
+	}
+	if root.Left().Key() != "V" || root.Left().Terminal() != "book" {
+		t.Errorf("Expected terminal V \"book\", got %s %q", root.Left().Key(), root.Left().Terminal())
+	}
+	if root.Left().Left() != nil || root.Left().Right() != nil {
+		t.Errorf("Terminal nodes should have no components")
+	}
+	if root.Right().Key() != "NP" || root.Right().Right().Terminal() != "flight" {
+		t.Errorf("Expected NP ending in \"flight\", got %s ending in %q", root.Right().Key(), root.Right().Right().Terminal())
+	}
+}
+
+func TestParseEqual(t *testing.T) {
+	grammar := append(panda(), Production{key: "V", nominals: []string{"eats"}})
+	words := []string{"the", "panda", "eats", "shoots", "and", "leaves"}
+	parses := Parses(words, grammar)
+	if len(parses) != 4 {
+		t.Fatalf("Expected 4 parses, got %d", len(parses))
+	}
+
+	type test struct {
+		left          int
+		right         int
+		expectedEqual bool
+	}
+
+	// The extra "eats" production doubles each parse without changing its structure
+	testCases := []test{
+		{left: 0, right: 0, expectedEqual: true},
+		{left: 0, right: 2, expectedEqual: true},
+		{left: 0, right: 1, expectedEqual: false},
+		{left: 1, right: 3, expectedEqual: true},
+		{left: 2, right: 3, expectedEqual: false},
+	}
+
+	for _, testCase := range testCases {
+		left, right := &parses[testCase.left], &parses[testCase.right]
+		if left.Equal(right) != testCase.expectedEqual {
+			t.Errorf("(Test %d == %d), expected %v", testCase.left, testCase.right, testCase.expectedEqual)
+		}
+		if testCase.expectedEqual && left.Hash() != right.Hash() {
+			t.Errorf("(Test %d == %d), equal parses should have equal hashes", testCase.left, testCase.right)
+		}
+	}
+
+	var missing *Parse
+	if missing.Equal(&parses[0]) || !missing.Equal(nil) {
+		t.Errorf("A nil parse should only equal another nil parse")
+	}
+}
+
+func TestParseWordsAndLeaves(t *testing.T) {
+	words := []string{"the", "panda", "eats", "shoots", "and", "leaves"}
+	parse := &Parses(words, panda())[0]
+
+	if actualWords := parse.Words(); !reflect.DeepEqual(words, actualWords) {
+		t.Errorf("Expected words %v, got %v", words, actualWords)
+	}
+
+	verbPhrase := parse.Subparses("VP2")[0]
+	expectedVerbPhraseWords := []string{"eats", "shoots", "and", "leaves"}
+	if actualWords := verbPhrase.Words(); !reflect.DeepEqual(expectedVerbPhraseWords, actualWords) {
+		t.Errorf("Expected words %v, got %v", expectedVerbPhraseWords, actualWords)
+	}
+
+	leaves := parse.Leaves()
+	if len(leaves) != len(words) {
+		t.Fatalf("Expected %d leaves, got %d", len(words), len(leaves))
+	}
+	for leafIndex, leaf := range leaves {
+		if leaf.Start != leafIndex || leaf.End != leafIndex+1 || leaf.Node.Terminal() != words[leafIndex] {
+			t.Errorf("Expected leaf %q spanning %d-%d, got %q spanning %d-%d", words[leafIndex], leafIndex, leafIndex+1, leaf.Node.Terminal(), leaf.Start, leaf.End)
+		}
+	}
+
+	// Each yield is its own slice, so changing one does not change another
+	nouns := parse.ProductionTerminals("N")
+	nouns[0][0] = "changed"
+	if nounPhrases := parse.ProductionTerminals("DN0"); nounPhrases[0][1] != "panda" {
+		t.Errorf("Expected yields not to share storage, got %v", nounPhrases)
+	}
+}
+
+func TestParseString(t *testing.T) {
+	parse := &Parses([]string{"book", "that", "flight"}, bookFlight())[0]
+	expectedString := "(VP (V book) (NP (DT that) (N flight)))"
+	if parse.String() != expectedString {
+		t.Errorf("Expected %s, got %s", expectedString, parse.String())
+	}
+}
+
+func TestParseSpan(t *testing.T) {
+	parse := &Parses([]string{"book", "that", "flight"}, bookFlight())[0]
+
+	type test struct {
+		name          string
+		node          *Parse
+		expectedStart int
+		expectedEnd   int
+		expectedOk    bool
+	}
+
+	testCases := []test{
+		{name: "root", node: parse, expectedStart: 0, expectedEnd: 3, expectedOk: true},
+		{name: "left", node: parse.Left(), expectedStart: 0, expectedEnd: 1, expectedOk: true},
+		{name: "right", node: parse.Right(), expectedStart: 1, expectedEnd: 3, expectedOk: true},
+		{name: "leaf", node: parse.Right().Right(), expectedStart: 2, expectedEnd: 3, expectedOk: true},
+		{name: "other parse", node: &Parses([]string{"book"}, bookFlight())[0], expectedOk: false},
+	}
+
+	for _, testCase := range testCases {
+		start, end, ok := parse.Span(testCase.node)
+		if start != testCase.expectedStart || end != testCase.expectedEnd || ok != testCase.expectedOk {
+			t.Errorf("(Test \"%s\"), expected %d-%d %v, got %d-%d %v", testCase.name, testCase.expectedStart, testCase.expectedEnd, testCase.expectedOk, start, end, ok)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+// Package parseval scores predicted parses against gold trees with the PARSEVAL measures.
+//
+// Each non-terminal node of a tree is reduced to a labelled bracket: its key and the span of words it covers.
+// Precision, recall and F1 compare the brackets of the predicted and gold trees.
+// Crossing brackets count predicted brackets that overlap a gold bracket without either containing the other.
+package parseval
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/kstafford3/gocky"
+	"github.com/kstafford3/gocky/treebank"
+)
+
+// Options controls which brackets are scored
+type Options struct {
+	// IgnoreSynthetic debinarizes both trees before scoring, so synthetic keys introduced by treebank.Binarize are not scored
+	IgnoreSynthetic bool
+	// IgnoreKeys lists keys whose brackets are not scored
+	IgnoreKeys []string
+}
+
+// Bracket is a labelled span of words, from Start up to but not including End
+type Bracket struct {
+	Label string
+	Start int
+	End   int
+}
+
+// Scores counts matching brackets
+type Scores struct {
+	Matched   int
+	Gold      int
+	Predicted int
+}
+
+// Precision is the share of predicted brackets that match a gold bracket
+func (s Scores) Precision() float64 {
+	if s.Predicted == 0 {
+		return 0
+	}
+	return float64(s.Matched) / float64(s.Predicted)
+}
+
+// Recall is the share of gold brackets that match a predicted bracket
+func (s Scores) Recall() float64 {
+	if s.Gold == 0 {
+		return 0
+	}
+	return float64(s.Matched) / float64(s.Gold)
+}
+
+// F1 is the harmonic mean of precision and recall
+func (s Scores) F1() float64 {
+	precision, recall := s.Precision(), s.Recall()
+	if precision+recall == 0 {
+		return 0
+	}
+	return 2 * precision * recall / (precision + recall)
+}
+
+// add accumulates other into the scores
+func (s *Scores) add(other Scores) {
+	s.Matched += other.Matched
+	s.Gold += other.Gold
+	s.Predicted += other.Predicted
+}
+
+// Report holds the scores for a test corpus
+type Report struct {
+	// Scores are the labelled bracket scores over every key
+	Scores
+	// Keys holds the labelled bracket scores for each key
+	Keys map[string]Scores
+	// Sentences is the number of sentences scored
+	Sentences int
+	// Unparsed is the number of sentences with no predicted parse
+	Unparsed int
+	// ExactMatches is the number of sentences whose brackets all match
+	ExactMatches int
+	// CrossingBrackets is the number of predicted brackets crossing a gold bracket
+	CrossingBrackets int
+}
+
+// ExactMatchRate is the share of sentences whose brackets all match
+func (r Report) ExactMatchRate() float64 {
+	if r.Sentences == 0 {
+		return 0
+	}
+	return float64(r.ExactMatches) / float64(r.Sentences)
+}
+
+// AverageCrossingBrackets is the mean number of crossing brackets per sentence
+func (r Report) AverageCrossingBrackets() float64 {
+	if r.Sentences == 0 {
+		return 0
+	}
+	return float64(r.CrossingBrackets) / float64(r.Sentences)
+}
+
+// Write prints the aggregate scores followed by the scores for each key
+func (r Report) Write(writer io.Writer) error {
+	table := tabwriter.NewWriter(writer, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(table, "sentences\t%d\n", r.Sentences)
+	fmt.Fprintf(table, "unparsed\t%d\n", r.Unparsed)
+	fmt.Fprintf(table, "exact match\t%.4f\n", r.ExactMatchRate())
+	fmt.Fprintf(table, "crossing brackets\t%.4f\n", r.AverageCrossingBrackets())
+	fmt.Fprintln(table)
+	fmt.Fprintln(table, "key\tgold\tpredicted\tmatched\tprecision\trecall\tf1")
+	keys := make([]string, 0, len(r.Keys))
+	for key := range r.Keys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		writeScores(table, key, r.Keys[key])
+	}
+	writeScores(table, "all", r.Scores)
+	return table.Flush()
+}
+
+// writeScores prints a row of the per-key table
+func writeScores(writer io.Writer, key string, scores Scores) {
+	fmt.Fprintf(writer, "%s\t%d\t%d\t%d\t%.4f\t%.4f\t%.4f\n", key, scores.Gold, scores.Predicted, scores.Matched, scores.Precision(), scores.Recall(), scores.F1())
+}
+
+// Evaluate scores each predicted parse against the gold tree at the same position
+// A nil predicted parse counts as a sentence the parser could not parse.
+func Evaluate(gold []*treebank.Tree, predicted []*gocky.Parse, options Options) (Report, error) {
+	if len(gold) != len(predicted) {
+		return Report{}, fmt.Errorf("parseval: %d gold trees but %d predicted parses", len(gold), len(predicted))
+	}
+	report := Report{Keys: map[string]Scores{}}
+	for sentenceIndex := range gold {
+		goldTree := gold[sentenceIndex]
+		var predictedTree *treebank.Tree
+		if predicted[sentenceIndex] != nil {
+			predictedTree = treebank.FromParse(predicted[sentenceIndex])
+			if goldLength, predictedLength := len(goldTree.Words()), len(predictedTree.Words()); goldLength != predictedLength {
+				return Report{}, fmt.Errorf("parseval: sentence %d has %d gold words but %d predicted words", sentenceIndex, goldLength, predictedLength)
+			}
+		}
+		report.add(goldTree, predictedTree, options)
+	}
+	return report, nil
+}
+
+// add scores a single sentence into the report
+func (r *Report) add(gold *treebank.Tree, predicted *treebank.Tree, options Options) {
+	r.Sentences++
+	goldBrackets := Brackets(gold, options)
+	predictedBrackets := []Bracket{}
+	if predicted == nil {
+		r.Unparsed++
+	} else {
+		predictedBrackets = Brackets(predicted, options)
+	}
+
+	unmatched := map[Bracket]int{}
+	for _, bracket := range goldBrackets {
+		unmatched[bracket]++
+		keyScores := r.Keys[bracket.Label]
+		keyScores.Gold++
+		r.Keys[bracket.Label] = keyScores
+	}
+	sentence := Scores{Gold: len(goldBrackets), Predicted: len(predictedBrackets)}
+	for _, bracket := range predictedBrackets {
+		keyScores := r.Keys[bracket.Label]
+		keyScores.Predicted++
+		if unmatched[bracket] > 0 {
+			unmatched[bracket]--
+			keyScores.Matched++
+			sentence.Matched++
+		}
+		r.Keys[bracket.Label] = keyScores
+		if crosses(bracket, goldBrackets) {
+			r.CrossingBrackets++
+		}
+	}
+	if predicted != nil && sentence.Matched == sentence.Gold && sentence.Matched == sentence.Predicted {
+		r.ExactMatches++
+	}
+	r.Scores.add(sentence)
+}
+
+// crosses reports whether the bracket overlaps any of the other brackets without one containing the other
+func crosses(bracket Bracket, others []Bracket) bool {
+	for _, other := range others {
+		if bracket.Start < other.Start && other.Start < bracket.End && bracket.End < other.End {
+			return true
+		}
+		if other.Start < bracket.Start && bracket.Start < other.End && other.End < bracket.End {
+			return true
+		}
+	}
+	return false
+}
+
+// Brackets lists the labelled brackets of every non-terminal node in the tree, in pre-order
+// Preterminal nodes are not bracketed, following PARSEVAL.
+func Brackets(tree *treebank.Tree, options Options) []Bracket {
+	if options.IgnoreSynthetic {
+		tree = treebank.Debinarize(tree)
+	}
+	brackets := []Bracket{}
+	collectBrackets(tree, 0, options, &brackets)
+	return brackets
+}
+
+// collectBrackets adds the brackets under the node starting at the given word, and returns the word after the node
+func collectBrackets(tree *treebank.Tree, start int, options Options, brackets *[]Bracket) int {
+	if tree.IsPreterminal() {
+		return start + len(tree.Words())
+	}
+	bracketIndex := len(*brackets)
+	if !contains(options.IgnoreKeys, tree.Label) {
+		*brackets = append(*brackets, Bracket{Label: tree.Label, Start: start})
+	}
+	end := start
+	for _, child := range tree.Children {
+		end = collectBrackets(child, end, options, brackets)
+	}
+	if !contains(options.IgnoreKeys, tree.Label) {
+		(*brackets)[bracketIndex].End = end
+	}
+	return end
+}
+
+// contains reports whether value is in searchSpace
+func contains(searchSpace []string, value string) bool {
+	for _, searchSpaceValue := range searchSpace {
+		if value == searchSpaceValue {
+			return true
+		}
+	}
+	return false
+}
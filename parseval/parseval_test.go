@@ -0,0 +1,147 @@
+package parseval
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/kstafford3/gocky"
+	"github.com/kstafford3/gocky/treebank"
+)
+
+func telescope() gocky.Grammar {
+	return gocky.Grammar{
+		gocky.TerminalProduction("DT", []string{"the"}),
+		gocky.TerminalProduction("N", []string{"man", "dog", "telescope"}),
+		gocky.TerminalProduction("V", []string{"saw"}),
+		gocky.TerminalProduction("P", []string{"with"}),
+		gocky.NonterminalProduction("NP", "DT", "N"),
+		gocky.NonterminalProduction("NP", "NP", "PP"),
+		gocky.NonterminalProduction("PP", "P", "NP"),
+		gocky.NonterminalProduction("VP", "V", "NP"),
+		gocky.NonterminalProduction("VP", "VP", "PP"),
+		gocky.NonterminalProduction("S", "NP", "VP"),
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	gold, err := treebank.ParseTree("(S (NP (DT the) (N man)) (VP (VP (V saw) (NP (DT the) (N dog))) (PP (P with) (NP (DT the) (N telescope)))))")
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	words := gold.Words()
+	parses := gocky.Parses(words, telescope())
+	if len(parses) != 2 {
+		t.Fatalf("Expected 2 parses, got %d", len(parses))
+	}
+
+	report, err := Evaluate([]*treebank.Tree{gold, gold, gold}, []*gocky.Parse{&parses[0], &parses[1], nil}, Options{})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	expectedScores := Scores{Matched: 13, Gold: 21, Predicted: 14}
+	if report.Scores != expectedScores {
+		t.Errorf("Expected scores %+v, got %+v", expectedScores, report.Scores)
+	}
+	expectedVerbPhraseScores := Scores{Matched: 3, Gold: 6, Predicted: 3}
+	if report.Keys["VP"] != expectedVerbPhraseScores {
+		t.Errorf("Expected VP scores %+v, got %+v", expectedVerbPhraseScores, report.Keys["VP"])
+	}
+	if report.Sentences != 3 || report.Unparsed != 1 {
+		t.Errorf("Expected 3 sentences with 1 unparsed, got %d with %d unparsed", report.Sentences, report.Unparsed)
+	}
+	if report.ExactMatches != 1 {
+		t.Errorf("Expected 1 exact match, got %d", report.ExactMatches)
+	}
+	if report.CrossingBrackets != 1 {
+		t.Errorf("Expected 1 crossing bracket, got %d", report.CrossingBrackets)
+	}
+	if precision := report.Precision(); precision != 13.0/14.0 {
+		t.Errorf("Expected precision %f, got %f", 13.0/14.0, precision)
+	}
+
+	output := &bytes.Buffer{}
+	if err := report.Write(output); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if !strings.Contains(output.String(), "exact match") || !strings.Contains(output.String(), "VP") {
+		t.Errorf("Expected the report to list exact matches and keys, got %s", output.String())
+	}
+}
+
+func TestEvaluateIgnoreSynthetic(t *testing.T) {
+	grammar := gocky.Grammar{
+		gocky.TerminalProduction("DT", []string{"the"}),
+		gocky.TerminalProduction("JJ", []string{"big"}),
+		gocky.TerminalProduction("N", []string{"dog"}),
+		gocky.NonterminalProduction("NP", "DT", "@NP"),
+		gocky.NonterminalProduction("@NP", "JJ", "N"),
+	}
+	gold, err := treebank.ParseTree("(NP (DT the) (JJ big) (N dog))")
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	parses := gocky.Parses(gold.Words(), grammar)
+	if len(parses) != 1 {
+		t.Fatalf("Expected 1 parse, got %d", len(parses))
+	}
+
+	type test struct {
+		name           string
+		options        Options
+		expectedScores Scores
+	}
+
+	testCases := []test{
+		{name: "synthetic", options: Options{}, expectedScores: Scores{Matched: 1, Gold: 1, Predicted: 2}},
+		{name: "ignore synthetic", options: Options{IgnoreSynthetic: true}, expectedScores: Scores{Matched: 1, Gold: 1, Predicted: 1}},
+		{name: "ignore keys", options: Options{IgnoreKeys: []string{"@NP"}}, expectedScores: Scores{Matched: 1, Gold: 1, Predicted: 1}},
+	}
+
+	for _, testCase := range testCases {
+		report, err := Evaluate([]*treebank.Tree{gold}, []*gocky.Parse{&parses[0]}, testCase.options)
+		if err != nil {
+			t.Fatalf("(Test \"%s\"), unexpected error %v", testCase.name, err)
+		}
+		if report.Scores != testCase.expectedScores {
+			t.Errorf("(Test \"%s\"), expected scores %+v, got %+v", testCase.name, testCase.expectedScores, report.Scores)
+		}
+	}
+}
+
+func TestEvaluateMismatch(t *testing.T) {
+	gold, err := treebank.ParseTree("(S (N dogs) (V bark))")
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if _, err := Evaluate([]*treebank.Tree{gold}, []*gocky.Parse{}, Options{}); err == nil {
+		t.Errorf("Expected an error for a missing parse")
+	}
+
+	parses := gocky.Parses([]string{"the", "dog"}, gocky.Grammar{
+		gocky.TerminalProduction("DT", []string{"the"}),
+		gocky.TerminalProduction("N", []string{"dog"}),
+		gocky.NonterminalProduction("NP", "DT", "N"),
+	})
+	if _, err := Evaluate([]*treebank.Tree{treebank.Binarize(&treebank.Tree{Label: "X", Word: "one"})}, []*gocky.Parse{&parses[0]}, Options{}); err == nil {
+		t.Errorf("Expected an error for sentences of different lengths")
+	}
+}
+
+func TestBrackets(t *testing.T) {
+	tree, err := treebank.ParseTree("(S (NP (DT the) (N dog)) (V barks))")
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	expectedBrackets := []Bracket{{Label: "S", Start: 0, End: 3}, {Label: "NP", Start: 0, End: 2}}
+	brackets := Brackets(tree, Options{})
+	if len(brackets) != len(expectedBrackets) {
+		t.Fatalf("Expected brackets %v, got %v", expectedBrackets, brackets)
+	}
+	for bracketIndex := range expectedBrackets {
+		if brackets[bracketIndex] != expectedBrackets[bracketIndex] {
+			t.Errorf("Expected brackets %v, got %v", expectedBrackets, brackets)
+		}
+	}
+}
@@ -0,0 +1,278 @@
+package gocky
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Profile summarises the work a grammar does over a corpus, to find the productions that make parsing blow up
+// Most counts are taken from the CKY chart of each sentence, so they cover every constituent built, not only those in complete parses.
+// Production uses and ambiguity are taken from the complete parses.
+type Profile struct {
+	// Sentences is the number of sentences profiled
+	Sentences int
+	// Parsed is the number of sentences with at least one complete parse
+	Parsed int
+	// AmbiguousSentences is the number of sentences with more than one complete parse
+	AmbiguousSentences int
+	// TotalParses is the number of complete parses over every sentence
+	TotalParses int
+	// MaxParses is the largest number of complete parses for one sentence
+	MaxParses int
+	// MaxParsesWords is the first sentence with MaxParses parses
+	MaxParsesWords []string
+	// Combinations is the number of left and right constituent pairs tried while filling the charts
+	Combinations int
+	// Cells is the number of chart cells holding at least one constituent
+	Cells int
+	// AmbiguousCells is the number of chart cells holding more than one constituent, with the same key or different keys
+	// Each is a span of words with more than one analysis.
+	AmbiguousCells int
+	// Productions profiles each production of the grammar, in grammar order
+	Productions []ProductionProfile
+	// Keys profiles each key with constituents in a chart, ordered by most constituents first
+	Keys []KeyProfile
+	// Abandoned lists the sentences given up on by AddContext, in the order they were added
+	// They are left out of every other count.
+	Abandoned []AbandonedSentence
+}
+
+// AbandonedSentence is a sentence the profiler gave up on, with the *LimitError or context error that stopped it
+type AbandonedSentence struct {
+	Words []string
+	Err   error
+}
+
+// ProductionProfile counts the work done by one production of a grammar
+type ProductionProfile struct {
+	Production Production
+	// Index is the position of the production in the grammar
+	Index int
+	// Constituents is the number of chart entries the production built
+	Constituents int
+	// Uses is the number of nodes of complete parses built by the production
+	Uses int
+	// Ambiguity counts the complete parses that use the production, in sentences where other complete parses do not
+	// Productions used by every parse of a sentence do not tell its parses apart, so they are not blamed for its ambiguity.
+	Ambiguity int
+}
+
+// KeyProfile counts the chart cells filled with constituents of one key
+type KeyProfile struct {
+	Key string
+	// Cells is the number of chart cells holding at least one constituent with the key
+	Cells int
+	// AmbiguousCells is the number of chart cells holding a constituent with the key and any other constituent
+	AmbiguousCells int
+	// Constituents is the number of chart entries with the key
+	Constituents int
+}
+
+// AverageParses returns the mean number of complete parses per sentence
+func (p Profile) AverageParses() float64 {
+	if p.Sentences == 0 {
+		return 0
+	}
+	return float64(p.TotalParses) / float64(p.Sentences)
+}
+
+// MostAmbiguous returns up to n productions with the most Ambiguity, most first
+// Productions that never added ambiguity are left out, and n of zero or less returns all the rest.
+func (p Profile) MostAmbiguous(n int) []ProductionProfile {
+	ambiguous := []ProductionProfile{}
+	for _, production := range p.Productions {
+		if production.Ambiguity > 0 {
+			ambiguous = append(ambiguous, production)
+		}
+	}
+	sort.SliceStable(ambiguous, func(i, j int) bool {
+		return ambiguous[i].Ambiguity > ambiguous[j].Ambiguity
+	})
+	if n > 0 && len(ambiguous) > n {
+		ambiguous = ambiguous[:n]
+	}
+	return ambiguous
+}
+
+// Write writes the profile as a plain text report, listing the top most ambiguous productions
+func (p Profile) Write(writer io.Writer, top int) error {
+	report := &strings.Builder{}
+	fmt.Fprintf(report, "sentences: %d (%d parsed, %d ambiguous)\n", p.Sentences, p.Parsed, p.AmbiguousSentences)
+	fmt.Fprintf(report, "parses: %.2f average, %d max", p.AverageParses(), p.MaxParses)
+	if p.MaxParses > 0 {
+		fmt.Fprintf(report, " for %q", strings.Join(p.MaxParsesWords, " "))
+	}
+	fmt.Fprintf(report, "\ncells: %d (%d ambiguous)\n", p.Cells, p.AmbiguousCells)
+	fmt.Fprintf(report, "combinations: %d\n", p.Combinations)
+	fmt.Fprintln(report, "\nproductions:")
+	for _, production := range p.Productions {
+		fmt.Fprintf(report, "  %-30s %6d built %6d used %6d ambiguous\n", production.Production, production.Constituents, production.Uses, production.Ambiguity)
+	}
+	fmt.Fprintln(report, "\nkeys:")
+	for _, key := range p.Keys {
+		fmt.Fprintf(report, "  %-30s %6d cells %6d ambiguous %6d built\n", key.Key, key.Cells, key.AmbiguousCells, key.Constituents)
+	}
+	fmt.Fprintln(report, "\nmost ambiguous:")
+	for _, production := range p.MostAmbiguous(top) {
+		fmt.Fprintf(report, "  %-30s %6d\n", production.Production, production.Ambiguity)
+	}
+	if len(p.Abandoned) > 0 {
+		fmt.Fprintf(report, "\nabandoned: %d\n", len(p.Abandoned))
+		for _, abandoned := range p.Abandoned {
+			fmt.Fprintf(report, "  %q: %v\n", strings.Join(abandoned.Words, " "), abandoned.Err)
+		}
+	}
+	_, err := io.WriteString(writer, report.String())
+	return err
+}
+
+// Profiler builds a Profile one sentence at a time
+// The grammar is indexed once, and the profiler reuses its chart between sentences, so it is not safe for use by multiple goroutines.
+type Profiler struct {
+	parser      *parser
+	positions   map[*Production]int
+	productions []ProductionProfile
+	keys        map[string]*KeyProfile
+	profile     Profile
+}
+
+// NewProfiler creates a profiler for the grammar
+func NewProfiler(grammar Grammar) *Profiler {
+	index := indexGrammar(grammar)
+	profiler := &Profiler{
+		parser:      newParser(index),
+		positions:   map[*Production]int{},
+		productions: make([]ProductionProfile, len(grammar)),
+		keys:        map[string]*KeyProfile{},
+	}
+	for productionIndex := range index.grammar {
+		profiler.positions[&index.grammar[productionIndex]] = productionIndex
+		profiler.productions[productionIndex] = ProductionProfile{Production: grammar[productionIndex], Index: productionIndex}
+	}
+	return profiler
+}
+
+// ProfileCorpus parses every sentence of the corpus and returns the profile of the work done
+func ProfileCorpus(corpus [][]string, grammar Grammar) Profile {
+	profiler := NewProfiler(grammar)
+	for _, words := range corpus {
+		profiler.Add(words)
+	}
+	return profiler.Profile()
+}
+
+// Add parses the words and adds the work done to the profile
+func (p *Profiler) Add(words []string) {
+	p.AddContext(context.Background(), words, ParseOptions{})
+}
+
+// AddContext parses the words like ParsesContext and adds the work done to the profile
+// A sentence that could not be parsed within the options or before the context was done is only listed in Profile.Abandoned,
+// and the error is returned.
+func (p *Profiler) AddContext(ctx context.Context, words []string, options ParseOptions) error {
+	parses, err := p.parser.parse(ctx, words, options)
+	if err != nil {
+		p.profile.Abandoned = append(p.profile.Abandoned, AbandonedSentence{Words: append([]string{}, words...), Err: err})
+		return err
+	}
+	p.profile.Sentences++
+	if len(parses) > 0 {
+		p.profile.Parsed++
+	}
+	if len(parses) > 1 {
+		p.profile.AmbiguousSentences++
+	}
+	p.profile.TotalParses += len(parses)
+	if len(parses) > p.profile.MaxParses {
+		p.profile.MaxParses = len(parses)
+		p.profile.MaxParsesWords = append([]string{}, words...)
+	}
+	for startIndex := 0; startIndex < len(words); startIndex++ {
+		for endIndex := startIndex + 1; endIndex <= len(words); endIndex++ {
+			p.addCell(p.parser.table[startIndex][endIndex])
+			for splitIndex := startIndex + 1; splitIndex < endIndex; splitIndex++ {
+				p.profile.Combinations += len(p.parser.table[startIndex][splitIndex]) * len(p.parser.table[splitIndex][endIndex])
+			}
+		}
+	}
+	p.addParses(parses)
+	return nil
+}
+
+// addCell counts the constituents of one chart cell by production and key
+// A cell with more than one constituent is ambiguous for every key in it.
+func (p *Profiler) addCell(cell []Parse) {
+	if len(cell) == 0 {
+		return
+	}
+	p.profile.Cells++
+	if len(cell) > 1 {
+		p.profile.AmbiguousCells++
+	}
+	counts := map[string]int{}
+	for _, parse := range cell {
+		counts[parse.production.key]++
+		if position, ok := p.positions[parse.production]; ok {
+			p.productions[position].Constituents++
+		}
+	}
+	for key, count := range counts {
+		keyProfile, ok := p.keys[key]
+		if !ok {
+			keyProfile = &KeyProfile{Key: key}
+			p.keys[key] = keyProfile
+		}
+		keyProfile.Cells++
+		keyProfile.Constituents += count
+		if len(cell) > 1 {
+			keyProfile.AmbiguousCells++
+		}
+	}
+}
+
+// addParses counts the productions used by the complete parses of a sentence
+// A production used by some of the parses but not all of them is one of the choices that tells the parses apart,
+// so it is blamed once for each parse that uses it.
+func (p *Profiler) addParses(parses []Parse) {
+	parsesUsing := map[int]int{}
+	for parseIndex := range parses {
+		used := map[int]bool{}
+		Walk(&parses[parseIndex], func(node *Parse, depth int) WalkAction {
+			if position, ok := p.positions[node.production]; ok {
+				p.productions[position].Uses++
+				used[position] = true
+			}
+			return WalkContinue
+		})
+		for position := range used {
+			parsesUsing[position]++
+		}
+	}
+	for position, count := range parsesUsing {
+		if count < len(parses) {
+			p.productions[position].Ambiguity += count
+		}
+	}
+}
+
+// Profile returns the profile of the sentences added so far
+func (p *Profiler) Profile() Profile {
+	profile := p.profile
+	profile.MaxParsesWords = append([]string{}, p.profile.MaxParsesWords...)
+	profile.Abandoned = append([]AbandonedSentence{}, p.profile.Abandoned...)
+	profile.Productions = append([]ProductionProfile{}, p.productions...)
+	profile.Keys = make([]KeyProfile, 0, len(p.keys))
+	for _, keyProfile := range p.keys {
+		profile.Keys = append(profile.Keys, *keyProfile)
+	}
+	sort.Slice(profile.Keys, func(i, j int) bool {
+		if profile.Keys[i].Constituents != profile.Keys[j].Constituents {
+			return profile.Keys[i].Constituents > profile.Keys[j].Constituents
+		}
+		return profile.Keys[i].Key < profile.Keys[j].Key
+	})
+	return profile
+}
@@ -0,0 +1,159 @@
+package gocky
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestProfileCorpus(t *testing.T) {
+	corpus := [][]string{
+		{"look", "up", "New", "York"},
+		{"up", "look"},
+	}
+	profile := ProfileCorpus(corpus, lookUpNewYork())
+
+	if profile.Sentences != 2 || profile.Parsed != 1 || profile.AmbiguousSentences != 1 {
+		t.Errorf("Expected 2 sentences with 1 parsed and 1 ambiguous, got %d with %d parsed and %d ambiguous", profile.Sentences, profile.Parsed, profile.AmbiguousSentences)
+	}
+	if profile.TotalParses != 4 || profile.MaxParses != 4 || profile.AverageParses() != 2 {
+		t.Errorf("Expected 4 parses, 4 at most and 2 on average, got %d, %d and %v", profile.TotalParses, profile.MaxParses, profile.AverageParses())
+	}
+	if !reflect.DeepEqual(profile.MaxParsesWords, corpus[0]) {
+		t.Errorf("Expected the most parses for %v, got %v", corpus[0], profile.MaxParsesWords)
+	}
+	if profile.Combinations != 11 {
+		t.Errorf("Expected 11 combinations, got %d", profile.Combinations)
+	}
+	if profile.Cells != 10 || profile.AmbiguousCells != 3 {
+		t.Errorf("Expected 10 cells with 3 ambiguous, got %d with %d ambiguous", profile.Cells, profile.AmbiguousCells)
+	}
+
+	type counts struct {
+		constituents int
+		uses         int
+		ambiguity    int
+	}
+	expectedProductions := []counts{
+		{constituents: 3, uses: 4},               // V -> "look up" | "look"
+		{constituents: 2, uses: 2, ambiguity: 2}, // P -> "up"
+		{constituents: 2, uses: 4},               // N -> "New York" | "York"
+		{constituents: 1, uses: 2, ambiguity: 2}, // J -> "New"
+		{constituents: 1, uses: 2, ambiguity: 2}, // N -> J N
+		{constituents: 2, uses: 2, ambiguity: 2}, // PP -> P N
+		{constituents: 2, uses: 2, ambiguity: 2}, // VP -> V N
+		{constituents: 2, uses: 2, ambiguity: 2}, // VP -> V PP
+	}
+	for productionIndex, expected := range expectedProductions {
+		production := profile.Productions[productionIndex]
+		actual := counts{constituents: production.Constituents, uses: production.Uses, ambiguity: production.Ambiguity}
+		if production.Index != productionIndex || actual != expected {
+			t.Errorf("(Test \"%s\"), expected %+v, got %+v", production.Production, expected, actual)
+		}
+	}
+
+	expectedKeys := []KeyProfile{
+		{Key: "VP", Cells: 1, AmbiguousCells: 1, Constituents: 4},
+		{Key: "N", Cells: 2, AmbiguousCells: 1, Constituents: 3},
+		{Key: "V", Cells: 3, Constituents: 3},
+		{Key: "P", Cells: 2, Constituents: 2},
+		{Key: "PP", Cells: 1, AmbiguousCells: 1, Constituents: 2},
+		{Key: "J", Cells: 1, Constituents: 1},
+	}
+	if !reflect.DeepEqual(profile.Keys, expectedKeys) {
+		t.Errorf("Expected keys %+v, got %+v", expectedKeys, profile.Keys)
+	}
+
+	mostAmbiguous := []int{}
+	for _, production := range profile.MostAmbiguous(2) {
+		mostAmbiguous = append(mostAmbiguous, production.Index)
+	}
+	if !reflect.DeepEqual(mostAmbiguous, []int{1, 3}) {
+		t.Errorf("Expected the most ambiguous productions to be [1 3], got %v", mostAmbiguous)
+	}
+	if all := profile.MostAmbiguous(0); len(all) != 6 {
+		t.Errorf("Expected 6 ambiguous productions, got %d", len(all))
+	}
+}
+
+func TestProfileCorpusPanda(t *testing.T) {
+	corpus := [][]string{
+		{"the", "panda", "eats", "shoots", "and", "leaves"},
+		{"the", "panda", "eats"},
+	}
+	profile := ProfileCorpus(corpus, panda())
+
+	if profile.AmbiguousSentences != 1 || profile.MaxParses != 2 {
+		t.Errorf("Expected 1 ambiguous sentence with 2 parses, got %d with %d parses", profile.AmbiguousSentences, profile.MaxParses)
+	}
+	if profile.AmbiguousCells != 6 {
+		t.Errorf("Expected 6 ambiguous cells, got %d", profile.AmbiguousCells)
+	}
+
+	// Each parse is told apart by the productions only it uses, whatever their order in the grammar
+	ambiguousKeys := []string{}
+	for _, production := range profile.MostAmbiguous(0) {
+		if production.Ambiguity != 1 {
+			t.Errorf("(Test \"%s\"), expected ambiguity 1, got %d", production.Production, production.Ambiguity)
+		}
+		ambiguousKeys = append(ambiguousKeys, production.Production.Key())
+	}
+	expectedKeys := []string{"CCN", "NP0", "CCV", "VP0", "VP1", "VP2", "S2", "S3"}
+	if !reflect.DeepEqual(ambiguousKeys, expectedKeys) {
+		t.Errorf("Expected the ambiguous productions %v, got %v", expectedKeys, ambiguousKeys)
+	}
+
+	for _, key := range profile.Keys {
+		if key.Key == "V" && (key.Cells != 4 || key.AmbiguousCells != 2) {
+			t.Errorf("Expected V in 4 cells with 2 shared with nouns, got %d with %d ambiguous", key.Cells, key.AmbiguousCells)
+		}
+	}
+}
+
+func TestProfilerAddContext(t *testing.T) {
+	profiler := NewProfiler(lookUpNewYork())
+	err := profiler.AddContext(context.Background(), []string{"look", "up", "New", "York"}, ParseOptions{MaxWords: 3})
+	var limitErr *LimitError
+	if !errors.As(err, &limitErr) || limitErr.Limit != LimitWords {
+		t.Errorf("Expected a max words limit error, got %v", err)
+	}
+	profiler.Add([]string{"look", "York"})
+	profile := profiler.Profile()
+	if profile.Sentences != 1 || profile.TotalParses != 1 {
+		t.Errorf("Expected only the parsed sentence to be profiled, got %d sentences with %d parses", profile.Sentences, profile.TotalParses)
+	}
+	if len(profile.Abandoned) != 1 || profile.Abandoned[0].Err != err || len(profile.Abandoned[0].Words) != 4 {
+		t.Errorf("Expected the sentence over the limit to be abandoned, got %+v", profile.Abandoned)
+	}
+	buffer := &bytes.Buffer{}
+	if err := profile.Write(buffer, 1); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if expected := "abandoned: 1\n  \"look up New York\": gocky: parse exceeded"; !strings.Contains(buffer.String(), expected) {
+		t.Errorf("Expected the report to contain %q, got %q", expected, buffer.String())
+	}
+}
+
+func TestProfileWrite(t *testing.T) {
+	profile := ProfileCorpus([][]string{{"look", "up", "New", "York"}}, lookUpNewYork())
+	buffer := &bytes.Buffer{}
+	if err := profile.Write(buffer, 1); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	report := buffer.String()
+	expectedLines := []string{
+		"sentences: 1 (1 parsed, 1 ambiguous)",
+		"parses: 4.00 average, 4 max for \"look up New York\"",
+		"cells: 8 (3 ambiguous)",
+		"combinations: 10",
+		"most ambiguous:\n  P -> \"up\"                           2\n",
+	}
+	for _, expected := range expectedLines {
+		if !strings.Contains(report, expected) {
+			t.Errorf("Expected the report to contain %q, got %q", expected, report)
+		}
+	}
+}
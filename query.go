@@ -0,0 +1,323 @@
+package gocky
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Query selects nodes from a parse by key and by their position relative to other nodes
+//
+// Queries come in two forms. A path lists keys from parent to child, selecting the nodes matching the last key:
+//
+//	S/DN0/N     an N whose parent is a DN0 whose parent is an S
+//	S//N        an N anywhere below an S
+//	/S/DN0      a DN0 whose parent is the root of the parse, which must be an S
+//
+// A pattern names a key, then any number of relations that the node must have with other nodes:
+//
+//	VP < NP         a VP with an NP as a direct component
+//	VP << N         a VP with an N anywhere below it
+//	N > NP          an N that is a direct component of an NP
+//	N >> VP         an N anywhere below a VP
+//	NP $ V          an NP whose sibling is a V
+//	VP !<< NP       a VP without an NP anywhere below it
+//	VP < (NP << N)  a VP with a direct NP component, which has an N anywhere below it
+//
+// The key "_" matches any node. Every relation in a pattern applies to its first node, parentheses nest patterns.
+type Query struct {
+	source string
+	head   *queryNode
+}
+
+// queryNode is a key that a node must match, along with relations to other nodes
+type queryNode struct {
+	key       string
+	root      bool
+	relations []queryRelation
+}
+
+// queryRelation requires a node to have, or not have, a related node matching another queryNode
+type queryRelation struct {
+	operator string
+	negated  bool
+	node     *queryNode
+}
+
+// CompileQuery parses a query so that it can be used to select nodes from many parses
+func CompileQuery(query string) (*Query, error) {
+	tokens := tokenizeQuery(query)
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("gocky: empty query")
+	}
+	compiler := &queryCompiler{tokens: tokens}
+	var head *queryNode
+	var err error
+	if contains(tokens, "/") || contains(tokens, "//") {
+		head, err = compiler.path()
+	} else {
+		head, err = compiler.pattern()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if compiler.position < len(tokens) {
+		return nil, fmt.Errorf("gocky: unexpected %q in query %q", tokens[compiler.position], query)
+	}
+	return &Query{source: query, head: head}, nil
+}
+
+// MustCompileQuery is like CompileQuery, but panics if the query cannot be parsed
+func MustCompileQuery(query string) *Query {
+	compiled, err := CompileQuery(query)
+	if err != nil {
+		panic(err)
+	}
+	return compiled
+}
+
+// String returns the source of the query
+func (q *Query) String() string {
+	return q.source
+}
+
+// Select returns every node of the parse matching the query, in pre-order
+func (q *Query) Select(parse *Parse) []*Parse {
+	tree := newQueryTree(parse)
+	matches := []*Parse{}
+	for _, node := range tree.nodes {
+		if tree.matches(node, q.head) {
+			matches = append(matches, node)
+		}
+	}
+	return matches
+}
+
+// Query selects every node of the parse matching the query, in pre-order
+// See Query for the query syntax.
+func (p *Parse) Query(query string) ([]*Parse, error) {
+	compiled, err := CompileQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	return compiled.Select(p), nil
+}
+
+// queryOperators are the relation operators, longest first so that "<<" is not read as "<"
+var queryOperators = []string{"<<", ">>", "<", ">", "$"}
+
+// tokenizeQuery splits a query into keys, operators, brackets and slashes
+func tokenizeQuery(query string) []string {
+	tokens := []string{}
+	runes := []rune(query)
+	for position := 0; position < len(runes); {
+		character := runes[position]
+		switch {
+		case unicode.IsSpace(character):
+			position++
+		case character == '(' || character == ')' || character == '!' || character == '$':
+			tokens = append(tokens, string(character))
+			position++
+		case character == '<' || character == '>' || character == '/':
+			if position+1 < len(runes) && runes[position+1] == character {
+				tokens = append(tokens, string([]rune{character, character}))
+				position += 2
+			} else {
+				tokens = append(tokens, string(character))
+				position++
+			}
+		default:
+			start := position
+			for position < len(runes) && !unicode.IsSpace(runes[position]) && !strings.ContainsRune("()!$<>/", runes[position]) {
+				position++
+			}
+			tokens = append(tokens, string(runes[start:position]))
+		}
+	}
+	return tokens
+}
+
+// queryCompiler reads query tokens into queryNodes
+type queryCompiler struct {
+	tokens   []string
+	position int
+}
+
+// peek returns the current token, or an empty string at the end of the query
+func (c *queryCompiler) peek() string {
+	if c.position < len(c.tokens) {
+		return c.tokens[c.position]
+	}
+	return ""
+}
+
+// key reads a single key
+func (c *queryCompiler) key() (string, error) {
+	token := c.peek()
+	if len(token) == 0 {
+		return "", fmt.Errorf("gocky: query ends where a key was expected")
+	}
+	if token == "(" || token == ")" || token == "!" || token == "/" || token == "//" || contains(queryOperators, token) {
+		return "", fmt.Errorf("gocky: expected a key in query but found %q", token)
+	}
+	c.position++
+	return token, nil
+}
+
+// path reads a path of keys, returning the node for the last key, related to its parents
+func (c *queryCompiler) path() (*queryNode, error) {
+	root := false
+	if c.peek() == "/" {
+		root = true
+		c.position++
+	}
+	key, err := c.key()
+	if err != nil {
+		return nil, err
+	}
+	node := &queryNode{key: key, root: root}
+	for c.peek() == "/" || c.peek() == "//" {
+		operator := ">"
+		if c.peek() == "//" {
+			operator = ">>"
+		}
+		c.position++
+		key, err := c.key()
+		if err != nil {
+			return nil, err
+		}
+		node = &queryNode{key: key, relations: []queryRelation{{operator: operator, node: node}}}
+	}
+	return node, nil
+}
+
+// pattern reads a node followed by its relations
+func (c *queryCompiler) pattern() (*queryNode, error) {
+	node, err := c.node()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		negated := false
+		if c.peek() == "!" {
+			negated = true
+			c.position++
+		}
+		operator := c.peek()
+		if !contains(queryOperators, operator) {
+			if negated {
+				return nil, fmt.Errorf("gocky: expected a relation after \"!\" in query")
+			}
+			return node, nil
+		}
+		c.position++
+		related, err := c.node()
+		if err != nil {
+			return nil, err
+		}
+		node.relations = append(node.relations, queryRelation{operator: operator, negated: negated, node: related})
+	}
+}
+
+// node reads a key, or a bracketed pattern
+func (c *queryCompiler) node() (*queryNode, error) {
+	if c.peek() != "(" {
+		key, err := c.key()
+		if err != nil {
+			return nil, err
+		}
+		return &queryNode{key: key}, nil
+	}
+	c.position++
+	node, err := c.pattern()
+	if err != nil {
+		return nil, err
+	}
+	if c.peek() != ")" {
+		return nil, fmt.Errorf("gocky: missing \")\" in query")
+	}
+	c.position++
+	return node, nil
+}
+
+// queryTree holds the nodes of a parse with links back to their parents
+type queryTree struct {
+	root    *Parse
+	nodes   []*Parse
+	parents map[*Parse]*Parse
+}
+
+// newQueryTree lists the nodes of a parse in pre-order and records their parents
+func newQueryTree(parse *Parse) *queryTree {
+	tree := &queryTree{root: parse, parents: map[*Parse]*Parse{}}
+	ancestors := []*Parse{}
+	Walk(parse, func(node *Parse, depth int) WalkAction {
+		ancestors = append(ancestors[:depth], node)
+		if depth > 0 {
+			tree.parents[node] = ancestors[depth-1]
+		}
+		tree.nodes = append(tree.nodes, node)
+		return WalkContinue
+	})
+	return tree
+}
+
+// matches reports whether the node matches the query node and all of its relations
+func (t *queryTree) matches(node *Parse, query *queryNode) bool {
+	if query.key != "_" && query.key != node.production.key {
+		return false
+	}
+	if query.root && node != t.root {
+		return false
+	}
+	for _, relation := range query.relations {
+		found := false
+		for _, related := range t.related(node, relation.operator) {
+			if t.matches(related, relation.node) {
+				found = true
+				break
+			}
+		}
+		if found == relation.negated {
+			return false
+		}
+	}
+	return true
+}
+
+// related lists the nodes that have the operator's relation with the node
+func (t *queryTree) related(node *Parse, operator string) []*Parse {
+	related := []*Parse{}
+	switch operator {
+	case "<":
+		for _, child := range []*Parse{node.left, node.right} {
+			if child != nil {
+				related = append(related, child)
+			}
+		}
+	case "<<":
+		Walk(node, func(descendant *Parse, depth int) WalkAction {
+			if depth > 0 {
+				related = append(related, descendant)
+			}
+			return WalkContinue
+		})
+	case ">":
+		if parent, ok := t.parents[node]; ok {
+			related = append(related, parent)
+		}
+	case ">>":
+		for parent, ok := t.parents[node]; ok; parent, ok = t.parents[parent] {
+			related = append(related, parent)
+		}
+	case "$":
+		if parent, ok := t.parents[node]; ok {
+			for _, sibling := range []*Parse{parent.left, parent.right} {
+				if sibling != nil && sibling != node {
+					related = append(related, sibling)
+				}
+			}
+		}
+	}
+	return related
+}
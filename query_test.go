@@ -0,0 +1,66 @@
+package gocky
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestQuery(t *testing.T) {
+	type test struct {
+		query             string
+		expectedTerminals [][][]string
+	}
+
+	// Parse 0 is (S3 (DN0 the panda) (VP2 eats (NP0 shoots (CCN and leaves))))
+	// Parse 1 is (S2 (DN0 the panda) (VP1 eats (VP0 shoots (CCV and leaves))))
+	testCases := []test{
+		{query: "N", expectedTerminals: [][][]string{{{"panda"}, {"shoots"}, {"leaves"}}, {{"panda"}}}},
+		{query: "DN0/N", expectedTerminals: [][][]string{{{"panda"}}, {{"panda"}}}},
+		{query: "S3/DN0/N", expectedTerminals: [][][]string{{{"panda"}}, {}}},
+		{query: "/S2/DN0", expectedTerminals: [][][]string{{}, {{"the", "panda"}}}},
+		{query: "/DN0", expectedTerminals: [][][]string{{}, {}}},
+		{query: "VP2//N", expectedTerminals: [][][]string{{{"shoots"}, {"leaves"}}, {}}},
+		{query: "_ < NP0", expectedTerminals: [][][]string{{{"eats", "shoots", "and", "leaves"}}, {}}},
+		{query: "V > VP0", expectedTerminals: [][][]string{{}, {{"shoots"}}}},
+		{query: "V >> VP0", expectedTerminals: [][][]string{{}, {{"shoots"}, {"leaves"}}}},
+		{query: "N $ CC", expectedTerminals: [][][]string{{{"leaves"}}, {}}},
+		{query: "_ < (NP0 << CC)", expectedTerminals: [][][]string{{{"eats", "shoots", "and", "leaves"}}, {}}},
+		{query: "_ < V < (_ << CC)", expectedTerminals: [][][]string{{{"eats", "shoots", "and", "leaves"}}, {{"eats", "shoots", "and", "leaves"}, {"shoots", "and", "leaves"}}}},
+		{query: "_ < V !<< N", expectedTerminals: [][][]string{{}, {{"eats", "shoots", "and", "leaves"}, {"shoots", "and", "leaves"}, {"and", "leaves"}}}},
+	}
+
+	parses := Parses([]string{"the", "panda", "eats", "shoots", "and", "leaves"}, panda())
+	for _, testCase := range testCases {
+		query, err := CompileQuery(testCase.query)
+		if err != nil {
+			t.Fatalf("(Test \"%s\"), unexpected error %v", testCase.query, err)
+		}
+		for parseIndex := range parses {
+			actualTerminals := [][]string{}
+			for _, match := range query.Select(&parses[parseIndex]) {
+				actualTerminals = append(actualTerminals, match.Words())
+			}
+			if !reflect.DeepEqual(testCase.expectedTerminals[parseIndex], actualTerminals) {
+				t.Errorf("(Test \"%s\"), parse %d expected %v, got %v", testCase.query, parseIndex, testCase.expectedTerminals[parseIndex], actualTerminals)
+			}
+		}
+	}
+}
+
+func TestQueryErrors(t *testing.T) {
+	testCases := []string{"", "VP <", "(VP < NP", "VP < NP)", "S/", "VP !", "< NP", "S/(DN0)"}
+	for _, testCase := range testCases {
+		if _, err := CompileQuery(testCase); err == nil {
+			t.Errorf("(Test \"%s\"), expected an error", testCase)
+		}
+	}
+
+	parse := Parses([]string{"book", "that", "flight"}, bookFlight())[0]
+	if _, err := parse.Query("VP <"); err == nil {
+		t.Errorf("Expected Parse.Query to report an invalid query")
+	}
+	matches, err := parse.Query("NP > VP")
+	if err != nil || len(matches) != 1 || matches[0].Key() != "NP" {
+		t.Errorf("Expected Parse.Query to find the NP, got %v %v", matches, err)
+	}
+}
@@ -0,0 +1,274 @@
+package gocky
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Registry holds named grammars that can be replaced while they are in use
+//
+// Each name refers to one version of its grammar at a time. Loading a new version swaps it in atomically:
+// parses that started with the old version finish with it, and later parses use the new one.
+// A version that fails to load or validate is reported and never swapped in, so the last good version stays in use.
+//
+// A Registry is safe for use by multiple goroutines.
+type Registry struct {
+	mutex   sync.RWMutex
+	entries map[string]*registryEntry
+}
+
+// registryEntry is the state of one name in a Registry
+type registryEntry struct {
+	path     string
+	modTime  time.Time
+	size     int64
+	current  *RegisteredGrammar
+	lastErr  error
+	loadLock sync.Mutex
+}
+
+// RegisteredGrammar is one version of a grammar in a Registry
+// It never changes once loaded, so it can be held on to for as long as a parse needs it.
+type RegisteredGrammar struct {
+	// Name is the name the grammar is registered under
+	Name string
+	// Path is the file the grammar was loaded from, empty for grammars set directly
+	Path string
+	// Version counts the versions loaded for the name, starting from 1
+	Version int
+	// LoadedAt is when this version was swapped in
+	LoadedAt time.Time
+	// Grammar holds the productions of this version, and must not be modified
+	Grammar Grammar
+	index   *grammarIndex
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{entries: map[string]*registryEntry{}}
+}
+
+// Load reads the grammar file, validates it, and swaps it in under the name
+// Later calls to Reload and Watch read the grammar from the same path.
+// When the file cannot be read or the grammar is not valid, the error is returned and recorded, and the previous version stays in use.
+func (r *Registry) Load(name string, path string) error {
+	entry := r.entry(name)
+	entry.loadLock.Lock()
+	defer entry.loadLock.Unlock()
+	r.mutex.Lock()
+	entry.path = path
+	r.mutex.Unlock()
+	return r.loadFile(name, entry)
+}
+
+// Set validates the grammar and swaps it in under the name
+// The productions are copied, so later changes to the caller's grammar do not change the version it becomes.
+// Names that are set rather than loaded have no file, so Reload returns an error for them and Watch skips them.
+func (r *Registry) Set(name string, grammar Grammar) error {
+	entry := r.entry(name)
+	entry.loadLock.Lock()
+	defer entry.loadLock.Unlock()
+	r.mutex.Lock()
+	entry.path = ""
+	r.mutex.Unlock()
+	return r.swap(name, entry, append(Grammar{}, grammar...), time.Time{}, 0)
+}
+
+// Reload reads the grammar for the name again from the file it was loaded from
+func (r *Registry) Reload(name string) error {
+	r.mutex.RLock()
+	entry, ok := r.entries[name]
+	r.mutex.RUnlock()
+	if !ok {
+		return fmt.Errorf("gocky: no grammar registered as %q", name)
+	}
+	entry.loadLock.Lock()
+	defer entry.loadLock.Unlock()
+	r.mutex.RLock()
+	path := entry.path
+	r.mutex.RUnlock()
+	if len(path) == 0 {
+		return fmt.Errorf("gocky: grammar %q was not loaded from a file", name)
+	}
+	return r.loadFile(name, entry)
+}
+
+// Watch polls the files of the loaded grammars every interval, reloading any whose size or modification time changed
+// Load errors are passed to onError, which may be nil, and the previous version stays in use.
+// Only the grammar files themselves are checked, not the files they import.
+// Watch returns when the context is done.
+func (r *Registry) Watch(ctx context.Context, interval time.Duration, onError func(name string, err error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		for _, name := range r.Names() {
+			changed, err := r.reloadChanged(name)
+			if changed && err != nil && onError != nil {
+				onError(name, err)
+			}
+		}
+	}
+}
+
+// Grammar returns the current version of the named grammar
+// The second result is false when no version has been loaded successfully.
+func (r *Registry) Grammar(name string) (*RegisteredGrammar, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	entry, ok := r.entries[name]
+	if !ok || entry.current == nil {
+		return nil, false
+	}
+	return entry.current, true
+}
+
+// Err returns the error from the most recent attempt to load the named grammar, or nil if it succeeded
+func (r *Registry) Err(name string) error {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	entry, ok := r.entries[name]
+	if !ok {
+		return nil
+	}
+	return entry.lastErr
+}
+
+// Names returns the registered names in sorted order, including names with no successfully loaded version
+func (r *Registry) Names() []string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	names := make([]string, 0, len(r.entries))
+	for name := range r.entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Parses parses the words with the current version of the named grammar, like Parses
+func (r *Registry) Parses(name string, words []string) ([]Parse, error) {
+	return r.ParsesContext(context.Background(), name, words, ParseOptions{})
+}
+
+// ParsesContext parses the words with the current version of the named grammar, like ParsesContext
+func (r *Registry) ParsesContext(ctx context.Context, name string, words []string, options ParseOptions) ([]Parse, error) {
+	grammar, ok := r.Grammar(name)
+	if !ok {
+		return nil, fmt.Errorf("gocky: no grammar registered as %q", name)
+	}
+	return grammar.ParsesContext(ctx, words, options)
+}
+
+// Parses parses the words with this version of the grammar, like Parses
+func (g *RegisteredGrammar) Parses(words []string) []Parse {
+	parses, _ := g.ParsesContext(context.Background(), words, ParseOptions{})
+	return parses
+}
+
+// ParsesContext parses the words with this version of the grammar, like ParsesContext
+// The grammar was indexed when it was loaded, so each parse skips that step.
+func (g *RegisteredGrammar) ParsesContext(ctx context.Context, words []string, options ParseOptions) ([]Parse, error) {
+	return newParser(g.index).parse(ctx, words, options)
+}
+
+// entry returns the entry for the name, creating it if needed
+func (r *Registry) entry(name string) *registryEntry {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	entry, ok := r.entries[name]
+	if !ok {
+		entry = &registryEntry{}
+		r.entries[name] = entry
+	}
+	return entry
+}
+
+// reloadChanged reloads the named grammar if its file has changed since it was last read, reporting whether it tried
+func (r *Registry) reloadChanged(name string) (bool, error) {
+	r.mutex.RLock()
+	entry := r.entries[name]
+	r.mutex.RUnlock()
+	entry.loadLock.Lock()
+	defer entry.loadLock.Unlock()
+	r.mutex.RLock()
+	path, modTime, size := entry.path, entry.modTime, entry.size
+	r.mutex.RUnlock()
+	if len(path) == 0 {
+		return false, nil
+	}
+	info, err := os.Stat(path)
+	if err != nil && size == -1 {
+		return false, nil
+	}
+	if err == nil && info.ModTime().Equal(modTime) && info.Size() == size {
+		return false, nil
+	}
+	return true, r.loadFile(name, entry)
+}
+
+// loadFile reads and swaps in the grammar at the entry's path
+// The caller holds the entry's loadLock.
+func (r *Registry) loadFile(name string, entry *registryEntry) error {
+	r.mutex.RLock()
+	path := entry.path
+	r.mutex.RUnlock()
+	info, err := os.Stat(path)
+	if err != nil {
+		return r.fail(entry, time.Time{}, -1, fmt.Errorf("gocky: loading grammar %q: %w", name, err))
+	}
+	grammar, err := LoadGrammar(path)
+	if err != nil {
+		return r.fail(entry, info.ModTime(), info.Size(), fmt.Errorf("gocky: loading grammar %q: %w", name, err))
+	}
+	return r.swap(name, entry, grammar, info.ModTime(), info.Size())
+}
+
+// swap validates the grammar and makes it the current version, or records why it could not
+// The file's modification time and size are recorded either way, so that Watch does not retry an unchanged file.
+// A file that could not be found is recorded with a size of -1, so that Watch only retries once it appears.
+func (r *Registry) swap(name string, entry *registryEntry, grammar Grammar, modTime time.Time, size int64) error {
+	if err := grammar.Validate(); err != nil {
+		return r.fail(entry, modTime, size, fmt.Errorf("gocky: loading grammar %q: %w", name, err))
+	}
+	version := 1
+	r.mutex.RLock()
+	if entry.current != nil {
+		version = entry.current.Version + 1
+	}
+	path := entry.path
+	r.mutex.RUnlock()
+	loaded := &RegisteredGrammar{
+		Name:     name,
+		Path:     path,
+		Version:  version,
+		LoadedAt: time.Now(),
+		Grammar:  grammar,
+		index:    indexGrammar(grammar),
+	}
+	r.mutex.Lock()
+	entry.current = loaded
+	entry.modTime = modTime
+	entry.size = size
+	entry.lastErr = nil
+	r.mutex.Unlock()
+	return nil
+}
+
+// fail records a load error for the entry and returns it
+func (r *Registry) fail(entry *registryEntry, modTime time.Time, size int64, err error) error {
+	r.mutex.Lock()
+	entry.modTime = modTime
+	entry.size = size
+	entry.lastErr = err
+	r.mutex.Unlock()
+	return err
+}
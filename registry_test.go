@@ -0,0 +1,195 @@
+package gocky
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// setModTime moves a grammar file's modification time so that a watcher sees the change
+func setModTime(t *testing.T, path string, modTime time.Time) {
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+}
+
+// registryKeys returns the keys of the parses the registry finds for the words
+func registryKeys(t *testing.T, registry *Registry, name string, words []string) []string {
+	parses, err := registry.Parses(name, words)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	keys := []string{}
+	for _, parse := range parses {
+		keys = append(keys, parse.Key())
+	}
+	return keys
+}
+
+func TestRegistryLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dog.gky")
+	writeGrammarFile(t, path, "DT -> \"the\"\nN -> \"dog\"\nNP -> DT N\n")
+
+	registry := NewRegistry()
+	if err := registry.Load("dog", path); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	first, ok := registry.Grammar("dog")
+	if !ok || first.Version != 1 || first.Path != path || len(first.Grammar) != 3 {
+		t.Fatalf("Expected version 1 of dog, got %+v", first)
+	}
+	if keys := registryKeys(t, registry, "dog", []string{"the", "dog"}); !reflect.DeepEqual(keys, []string{"NP"}) {
+		t.Errorf("Expected NP, got %v", keys)
+	}
+
+	type test struct {
+		name            string
+		grammar         string
+		expectedVersion int
+		expectedError   bool
+		expectedKeys    []string
+	}
+
+	testCases := []test{
+		{name: "new version", grammar: "DT -> \"the\"\nN -> \"cat\"\nNP -> DT N\n", expectedVersion: 2, expectedKeys: []string{"NP"}},
+		{name: "syntax error", grammar: "DT -> \"the\nN -> \"dog\"\n", expectedVersion: 2, expectedError: true, expectedKeys: []string{"NP"}},
+		{name: "invalid grammar", grammar: "N -> \"cat\"\nNP -> DT N\n", expectedVersion: 2, expectedError: true, expectedKeys: []string{"NP"}},
+		{name: "recovered", grammar: "N -> \"cat\"\n", expectedVersion: 3, expectedKeys: []string{}},
+	}
+
+	for _, testCase := range testCases {
+		writeGrammarFile(t, path, testCase.grammar)
+		err := registry.Reload("dog")
+		if (err != nil) != testCase.expectedError || (registry.Err("dog") != nil) != testCase.expectedError {
+			t.Errorf("(Test \"%s\"), expected error %v, got %v", testCase.name, testCase.expectedError, err)
+		}
+		current, _ := registry.Grammar("dog")
+		if current.Version != testCase.expectedVersion {
+			t.Errorf("(Test \"%s\"), expected version %d, got %d", testCase.name, testCase.expectedVersion, current.Version)
+		}
+		if keys := registryKeys(t, registry, "dog", []string{"the", "cat"}); !reflect.DeepEqual(keys, testCase.expectedKeys) {
+			t.Errorf("(Test \"%s\"), expected keys %v, got %v", testCase.name, testCase.expectedKeys, keys)
+		}
+	}
+
+	if parses := first.Parses([]string{"the", "dog"}); len(parses) != 1 {
+		t.Errorf("Expected the first version to keep parsing with its own grammar, got %v", parses)
+	}
+}
+
+func TestRegistryErrors(t *testing.T) {
+	registry := NewRegistry()
+	missing := filepath.Join(t.TempDir(), "missing.gky")
+
+	if err := registry.Load("missing", missing); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected a missing file error, got %v", err)
+	}
+	if _, ok := registry.Grammar("missing"); ok {
+		t.Errorf("Expected no grammar for a file that failed to load")
+	}
+	if _, err := registry.Parses("missing", []string{"dog"}); err == nil {
+		t.Errorf("Expected an error parsing with a grammar that failed to load")
+	}
+	if err := registry.Reload("unknown"); err == nil {
+		t.Errorf("Expected an error reloading an unknown name")
+	}
+
+	grammar := panda()
+	if err := registry.Set("panda", grammar); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	grammar[0] = TerminalProduction("DT", []string{"a"})
+	if current, _ := registry.Grammar("panda"); current.Grammar[0].nominals[0] != "the" || len(current.Parses([]string{"the", "panda", "eats"})) != 1 {
+		t.Errorf("Expected the registered grammar not to change with the caller's grammar, got %v", current.Grammar[0])
+	}
+	if err := registry.Reload("panda"); err == nil {
+		t.Errorf("Expected an error reloading a grammar that was set directly")
+	}
+	var grammarErr *GrammarError
+	if err := registry.Set("panda", bigDog()); !errors.As(err, &grammarErr) {
+		t.Errorf("Expected a GrammarError, got %v", err)
+	}
+	if current, ok := registry.Grammar("panda"); !ok || current.Version != 1 {
+		t.Errorf("Expected the valid panda grammar to stay in use, got %+v", current)
+	}
+	if names := registry.Names(); !reflect.DeepEqual(names, []string{"missing", "panda"}) {
+		t.Errorf("Expected names [missing panda], got %v", names)
+	}
+}
+
+func TestRegistryWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dog.gky")
+	start := time.Now().Add(-time.Hour)
+	writeGrammarFile(t, path, "N -> \"dog\"\n")
+	setModTime(t, path, start)
+
+	registry := NewRegistry()
+	if err := registry.Load("dog", path); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	grammar := panda()
+	if err := registry.Set("panda", grammar); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	grammar[0] = TerminalProduction("DT", []string{"a"})
+	if current, _ := registry.Grammar("panda"); current.Grammar[0].nominals[0] != "the" || len(current.Parses([]string{"the", "panda", "eats"})) != 1 {
+		t.Errorf("Expected the registered grammar not to change with the caller's grammar, got %v", current.Grammar[0])
+	}
+
+	errs := make(chan error, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		registry.Watch(ctx, time.Millisecond, func(name string, err error) {
+			errs <- err
+		})
+	}()
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	waitForVersion := func(version int) {
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			if current, _ := registry.Grammar("dog"); current.Version == version {
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+		t.Fatalf("Timed out waiting for version %d", version)
+	}
+
+	writeGrammarFile(t, path, "N -> \"cat\"\n")
+	setModTime(t, path, start.Add(time.Minute))
+	waitForVersion(2)
+
+	writeGrammarFile(t, path, "N -> N N N\n")
+	setModTime(t, path, start.Add(2*time.Minute))
+	select {
+	case err := <-errs:
+		if registry.Err("dog") == nil || err == nil {
+			t.Errorf("Expected the load error to be recorded, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for a load error")
+	}
+	if keys := registryKeys(t, registry, "dog", []string{"cat"}); !reflect.DeepEqual(keys, []string{"N"}) {
+		t.Errorf("Expected the last good grammar to stay in use, got %v", keys)
+	}
+
+	writeGrammarFile(t, path, "N -> \"cow\"\n")
+	setModTime(t, path, start.Add(3*time.Minute))
+	waitForVersion(3)
+	if current, _ := registry.Grammar("panda"); current.Version != 1 {
+		t.Errorf("Expected the panda grammar to be left alone, got version %d", current.Version)
+	}
+}
@@ -0,0 +1,67 @@
+package gocky
+
+import "fmt"
+
+// SemanticFunc computes the meaning of a node of a parse from the meanings of its components
+// For a terminal node, values is empty and the node's Terminal is usually the meaning.
+// For a non-terminal node, values holds the meanings of the left and right components.
+type SemanticFunc func(node *Parse, values []interface{}) (interface{}, error)
+
+// WithSemantics returns a copy of the production that computes meanings with the semantic function
+func (p Production) WithSemantics(semantics SemanticFunc) Production {
+	p.semantics = semantics
+	return p
+}
+
+// SemanticLeft is a SemanticFunc passing up the meaning of the left component
+func SemanticLeft(node *Parse, values []interface{}) (interface{}, error) {
+	return values[0], nil
+}
+
+// SemanticRight is a SemanticFunc passing up the meaning of the right component
+func SemanticRight(node *Parse, values []interface{}) (interface{}, error) {
+	return values[1], nil
+}
+
+// SemanticConstant creates a SemanticFunc that always means the given value
+func SemanticConstant(value interface{}) SemanticFunc {
+	return func(node *Parse, values []interface{}) (interface{}, error) {
+		return value, nil
+	}
+}
+
+// Evaluate computes the meaning of the parse bottom-up, using the semantic function of each node's production
+// Terminal nodes without a semantic function mean their terminal.
+// Non-terminal nodes without a semantic function mean a []interface{} holding the meanings of their left and right components.
+func (p *Parse) Evaluate() (interface{}, error) {
+	values := map[*Parse]interface{}{}
+	var err error
+	WalkPostOrder(p, func(node *Parse, depth int) WalkAction {
+		componentValues := []interface{}{}
+		for _, child := range []*Parse{node.left, node.right} {
+			if child != nil {
+				componentValues = append(componentValues, values[child])
+			}
+		}
+		if node.production.semantics == nil {
+			if len(componentValues) == 0 {
+				values[node] = node.terminal
+			} else {
+				values[node] = componentValues
+			}
+			return WalkContinue
+		}
+		var value interface{}
+		value, err = node.production.semantics(node, componentValues)
+		if err != nil {
+			err = fmt.Errorf("gocky: evaluating %s: %w", node.production.key, err)
+			return WalkStop
+		}
+		values[node] = value
+		return WalkContinue
+	})
+	if err != nil {
+		return nil, err
+	}
+	return values[p], nil
+}
@@ -0,0 +1,90 @@
+package gocky
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// flightCommand is the meaning of a sentence like "book that flight"
+type flightCommand struct {
+	Action string
+	Object string
+}
+
+func bookFlightSemantics() Grammar {
+	determiner := TerminalProduction("DT", []string{"the", "that", "a"})
+	noun := TerminalProduction("N", []string{"book", "flight"})
+	verb := TerminalProduction("V", []string{"book"}).WithSemantics(func(node *Parse, values []interface{}) (interface{}, error) {
+		return node.Terminal() + "ing", nil
+	})
+
+	NP := NonterminalProduction("NP", "DT", "N").WithSemantics(SemanticRight)
+	VP := NonterminalProduction("VP", "V", "NP").WithSemantics(func(node *Parse, values []interface{}) (interface{}, error) {
+		return flightCommand{Action: values[0].(string), Object: values[1].(string)}, nil
+	})
+	return Grammar{determiner, noun, verb, NP, VP}
+}
+
+func TestEvaluate(t *testing.T) {
+	type test struct {
+		name          string
+		grammar       Grammar
+		sentence      []string
+		expectedValue interface{}
+	}
+
+	testCases := []test{
+		{
+			name:          "semantics",
+			grammar:       bookFlightSemantics(),
+			sentence:      []string{"book", "that", "flight"},
+			expectedValue: flightCommand{Action: "booking", Object: "flight"},
+		},
+		{
+			name:          "defaults",
+			grammar:       bookFlight(),
+			sentence:      []string{"book", "that", "flight"},
+			expectedValue: []interface{}{"book", []interface{}{"that", "flight"}},
+		},
+		{
+			name: "constant",
+			grammar: Grammar{
+				TerminalProduction("N", []string{"yes", "yeah"}).WithSemantics(SemanticConstant(true)),
+				TerminalProduction("P", []string{"please"}),
+				NonterminalProduction("S", "N", "P").WithSemantics(SemanticLeft),
+			},
+			sentence:      []string{"yeah", "please"},
+			expectedValue: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		parses := Parses(testCase.sentence, testCase.grammar)
+		if len(parses) != 1 {
+			t.Fatalf("(Test \"%s\"), expected 1 parse, got %d", testCase.name, len(parses))
+		}
+		actualValue, err := parses[0].Evaluate()
+		if err != nil {
+			t.Fatalf("(Test \"%s\"), unexpected error %v", testCase.name, err)
+		}
+		if !reflect.DeepEqual(testCase.expectedValue, actualValue) {
+			t.Errorf("(Test \"%s\"), expected value %v, got %v", testCase.name, testCase.expectedValue, actualValue)
+		}
+	}
+}
+
+func TestEvaluateError(t *testing.T) {
+	unknownFlight := errors.New("unknown flight")
+	grammar := Grammar{
+		TerminalProduction("V", []string{"book"}),
+		TerminalProduction("N", []string{"flight"}).WithSemantics(func(node *Parse, values []interface{}) (interface{}, error) {
+			return nil, unknownFlight
+		}),
+		NonterminalProduction("VP", "V", "N"),
+	}
+	parses := Parses([]string{"book", "flight"}, grammar)
+	if _, err := parses[0].Evaluate(); !errors.Is(err, unknownFlight) {
+		t.Errorf("Expected the semantic error, got %v", err)
+	}
+}
@@ -0,0 +1,48 @@
+package gocky
+
+import (
+	"context"
+
+	"github.com/kstafford3/gocky/tokenizer"
+)
+
+// TextParse is a parse of text, together with the tokens its words came from
+type TextParse struct {
+	Parse
+	Tokens []tokenizer.Token
+}
+
+// ParseText splits the text into words with the default tokenizer, then parses the words like Parses
+// Each parse comes with the tokens of the text, so that TextParse.Offsets can find the text behind any node.
+func ParseText(text string, grammar Grammar) []TextParse {
+	parses, _ := ParseTextContext(context.Background(), text, grammar, nil, ParseOptions{})
+	return parses
+}
+
+// ParseTextContext splits the text into words with the tokenizer, then parses the words like ParsesContext
+// A nil tokenizer uses tokenizer.Default.
+func ParseTextContext(ctx context.Context, text string, grammar Grammar, textTokenizer *tokenizer.Tokenizer, options ParseOptions) ([]TextParse, error) {
+	if textTokenizer == nil {
+		textTokenizer = tokenizer.Default
+	}
+	tokens := textTokenizer.Tokenize(text)
+	parses, err := ckyParse(ctx, tokenizer.Words(tokens), grammar, options)
+	if err != nil {
+		return nil, err
+	}
+	textParses := make([]TextParse, len(parses))
+	for parseIndex, parse := range parses {
+		textParses[parseIndex] = TextParse{Parse: parse, Tokens: tokens}
+	}
+	return textParses, nil
+}
+
+// Offsets returns the byte offsets of the text covered by a node of the parse
+// End is not included, and ok is false when the node is not part of the parse.
+func (p *TextParse) Offsets(node *Parse) (start int, end int, ok bool) {
+	startWord, endWord, ok := p.Span(node)
+	if !ok {
+		return 0, 0, false
+	}
+	return p.Tokens[startWord].Start, p.Tokens[endWord-1].End, true
+}
@@ -0,0 +1,64 @@
+package gocky
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/kstafford3/gocky/tokenizer"
+)
+
+func TestParseText(t *testing.T) {
+	text := "  Book   that flight"
+	grammar := append(bookFlight(), TerminalProduction("V", []string{"Book"}))
+	parses := ParseText(text, grammar)
+	if len(parses) != 1 {
+		t.Fatalf("Expected 1 parse, got %d", len(parses))
+	}
+	parse := &parses[0]
+
+	type test struct {
+		name         string
+		node         *Parse
+		expectedText string
+	}
+
+	testCases := []test{
+		{name: "root", node: &parse.Parse, expectedText: "Book   that flight"},
+		{name: "verb", node: parse.Left(), expectedText: "Book"},
+		{name: "noun phrase", node: parse.Right(), expectedText: "that flight"},
+	}
+
+	for _, testCase := range testCases {
+		start, end, ok := parse.Offsets(testCase.node)
+		if !ok || text[start:end] != testCase.expectedText {
+			t.Errorf("(Test \"%s\"), expected %q, got %q (%v)", testCase.name, testCase.expectedText, text[start:end], ok)
+		}
+	}
+
+	if len(parse.Tokens) != 3 || parse.Tokens[2].Start != 14 {
+		t.Errorf("Expected the parse to keep its tokens, got %v", parse.Tokens)
+	}
+	if _, _, ok := parse.Offsets(Parses([]string{"book"}, bookFlight())[0].Left()); ok {
+		t.Errorf("Expected no offsets for a node of another parse")
+	}
+}
+
+func TestParseTextContext(t *testing.T) {
+	text := "Book THAT flight!"
+	lowercase := tokenizer.New(tokenizer.Options{Lowercase: true, DropPunctuation: true})
+	parses, err := ParseTextContext(context.Background(), text, bookFlight(), lowercase, ParseOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if len(parses) != 1 || !reflect.DeepEqual(parses[0].Words(), []string{"book", "that", "flight"}) {
+		t.Fatalf("Expected a parse of the lowercase words, got %d parses", len(parses))
+	}
+	if start, end, _ := parses[0].Offsets(parses[0].Right()); text[start:end] != "THAT flight" {
+		t.Errorf("Expected offsets into the original text, got %q", text[start:end])
+	}
+
+	if _, err := ParseTextContext(context.Background(), text, bookFlight(), nil, ParseOptions{MaxWords: 2}); err == nil {
+		t.Errorf("Expected a limit error")
+	}
+}
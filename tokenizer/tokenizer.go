@@ -0,0 +1,162 @@
+// Package tokenizer splits text into the words that gocky parses.
+//
+// Tokens are found with a few rules, applied to runs of characters between whitespace:
+//
+//	Words are runs of letters, marks and digits in any script, and may be joined by apostrophes and hyphens: "don't", "well-known"
+//	Numbers may contain "." and "," between digits: "3.14", "1,000"
+//	Every other character is punctuation, and a run of the same punctuation character is one token: "!", "..."
+//
+// Each token records its byte offsets in the text, so that parses can be traced back to the text they came from.
+package tokenizer
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Token is a word or punctuation mark found in a text
+// Start and End are byte offsets into the text, and End is not included in the token.
+type Token struct {
+	Text  string
+	Start int
+	End   int
+}
+
+// Options configures a Tokenizer
+// The zero value keeps contractions whole, keeps punctuation and keeps the case of the text.
+type Options struct {
+	// SplitContractions splits clitics such as "n't", "'s" and "'ll" from the word before them, as the Penn Treebank does
+	// "don't" becomes "do" and "n't", "she'll" becomes "she" and "'ll".
+	SplitContractions bool
+	// DropPunctuation leaves punctuation and symbols out of the tokens
+	DropPunctuation bool
+	// Lowercase lowercases the text of every token
+	// Offsets still refer to the original text.
+	Lowercase bool
+}
+
+// Tokenizer splits text into tokens with the rules described by the package
+type Tokenizer struct {
+	options Options
+}
+
+// New creates a tokenizer with the options
+func New(options Options) *Tokenizer {
+	return &Tokenizer{options: options}
+}
+
+// Default is the tokenizer used by Tokenize, with the zero Options
+var Default = New(Options{})
+
+// Tokenize splits text into tokens with the Default tokenizer
+func Tokenize(text string) []Token {
+	return Default.Tokenize(text)
+}
+
+// Words returns the text of each token
+func Words(tokens []Token) []string {
+	words := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		words = append(words, token.Text)
+	}
+	return words
+}
+
+// contractions are the clitics split off by Options.SplitContractions, written with a straight apostrophe
+var contractions = []string{"n't", "'s", "'m", "'d", "'re", "'ve", "'ll"}
+
+// Tokenize splits text into tokens, in the order they appear
+func (t *Tokenizer) Tokenize(text string) []Token {
+	tokens := []Token{}
+	for position := 0; position < len(text); {
+		character, size := utf8.DecodeRuneInString(text[position:])
+		switch {
+		case unicode.IsSpace(character):
+			position += size
+		case isWordCharacter(character):
+			end := wordEnd(text, position)
+			tokens = t.appendWord(tokens, text, position, end)
+			position = end
+		default:
+			end := position + size
+			for end < len(text) {
+				next, nextSize := utf8.DecodeRuneInString(text[end:])
+				if next != character {
+					break
+				}
+				end += nextSize
+			}
+			if !t.options.DropPunctuation {
+				tokens = t.appendToken(tokens, text, position, end)
+			}
+			position = end
+		}
+	}
+	return tokens
+}
+
+// appendWord adds the word spanning start to end, split from its contraction when the options ask for it
+func (t *Tokenizer) appendWord(tokens []Token, text string, start int, end int) []Token {
+	if t.options.SplitContractions {
+		word := strings.ToLower(strings.ReplaceAll(text[start:end], "’", "'"))
+		for _, contraction := range contractions {
+			if len(word) > len(contraction) && strings.HasSuffix(word, contraction) {
+				split := end
+				for runes := utf8.RuneCountInString(contraction); runes > 0; runes-- {
+					_, size := utf8.DecodeLastRuneInString(text[start:split])
+					split -= size
+				}
+				tokens = t.appendToken(tokens, text, start, split)
+				return t.appendToken(tokens, text, split, end)
+			}
+		}
+	}
+	return t.appendToken(tokens, text, start, end)
+}
+
+// appendToken adds the token spanning start to end
+func (t *Tokenizer) appendToken(tokens []Token, text string, start int, end int) []Token {
+	tokenText := text[start:end]
+	if t.options.Lowercase {
+		tokenText = strings.ToLower(tokenText)
+	}
+	return append(tokens, Token{Text: tokenText, Start: start, End: end})
+}
+
+// wordEnd finds the end of the word or number starting at start
+// Apostrophes and hyphens join word characters, "." and "," only join digits.
+func wordEnd(text string, start int) int {
+	end := start
+	previous := rune(0)
+	for end < len(text) {
+		character, size := utf8.DecodeRuneInString(text[end:])
+		if isWordCharacter(character) {
+			previous = character
+			end += size
+			continue
+		}
+		if end+size >= len(text) {
+			break
+		}
+		next, _ := utf8.DecodeRuneInString(text[end+size:])
+		joins := false
+		switch character {
+		case '\'', '’', '-':
+			joins = isWordCharacter(next)
+		case '.', ',':
+			joins = unicode.IsDigit(previous) && unicode.IsDigit(next)
+		}
+		if !joins {
+			break
+		}
+		previous = character
+		end += size
+	}
+	return end
+}
+
+// isWordCharacter reports whether the character can be part of a word
+func isWordCharacter(character rune) bool {
+	return unicode.IsLetter(character) || unicode.IsMark(character) || unicode.IsDigit(character)
+}
@@ -0,0 +1,127 @@
+package tokenizer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	type test struct {
+		name           string
+		text           string
+		options        Options
+		expectedTokens []Token
+	}
+
+	testCases := []test{
+		{
+			name: "words and punctuation",
+			text: "Book that flight, please!",
+			expectedTokens: []Token{
+				{Text: "Book", Start: 0, End: 4},
+				{Text: "that", Start: 5, End: 9},
+				{Text: "flight", Start: 10, End: 16},
+				{Text: ",", Start: 16, End: 17},
+				{Text: "please", Start: 18, End: 24},
+				{Text: "!", Start: 24, End: 25},
+			},
+		},
+		{
+			name:           "empty",
+			text:           " \t\n",
+			expectedTokens: []Token{},
+		},
+		{
+			name: "numbers",
+			text: "1,000 fish cost $3.50.",
+			expectedTokens: []Token{
+				{Text: "1,000", Start: 0, End: 5},
+				{Text: "fish", Start: 6, End: 10},
+				{Text: "cost", Start: 11, End: 15},
+				{Text: "$", Start: 16, End: 17},
+				{Text: "3.50", Start: 17, End: 21},
+				{Text: ".", Start: 21, End: 22},
+			},
+		},
+		{
+			name: "hyphens and repeated punctuation",
+			text: "a well-known dog... -- really",
+			expectedTokens: []Token{
+				{Text: "a", Start: 0, End: 1},
+				{Text: "well-known", Start: 2, End: 12},
+				{Text: "dog", Start: 13, End: 16},
+				{Text: "...", Start: 16, End: 19},
+				{Text: "--", Start: 20, End: 22},
+				{Text: "really", Start: 23, End: 29},
+			},
+		},
+		{
+			name: "contractions kept",
+			text: "don't 'quote'",
+			expectedTokens: []Token{
+				{Text: "don't", Start: 0, End: 5},
+				{Text: "'", Start: 6, End: 7},
+				{Text: "quote", Start: 7, End: 12},
+				{Text: "'", Start: 12, End: 13},
+			},
+		},
+		{
+			name:    "contractions split",
+			text:    "Don't, she'll say it’s",
+			options: Options{SplitContractions: true},
+			expectedTokens: []Token{
+				{Text: "Do", Start: 0, End: 2},
+				{Text: "n't", Start: 2, End: 5},
+				{Text: ",", Start: 5, End: 6},
+				{Text: "she", Start: 7, End: 10},
+				{Text: "'ll", Start: 10, End: 13},
+				{Text: "say", Start: 14, End: 17},
+				{Text: "it", Start: 18, End: 20},
+				{Text: "’s", Start: 20, End: 24},
+			},
+		},
+		{
+			name:    "dropped punctuation and lowercase",
+			text:    "The DOG, the Cat.",
+			options: Options{DropPunctuation: true, Lowercase: true},
+			expectedTokens: []Token{
+				{Text: "the", Start: 0, End: 3},
+				{Text: "dog", Start: 4, End: 7},
+				{Text: "the", Start: 9, End: 12},
+				{Text: "cat", Start: 13, End: 16},
+			},
+		},
+		{
+			name: "unicode",
+			text: "Ça coûte 5€ — naïve",
+			expectedTokens: []Token{
+				{Text: "Ça", Start: 0, End: 3},
+				{Text: "coûte", Start: 4, End: 10},
+				{Text: "5", Start: 11, End: 12},
+				{Text: "€", Start: 12, End: 15},
+				{Text: "—", Start: 16, End: 19},
+				{Text: "naïve", Start: 20, End: 26},
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		tokens := New(testCase.options).Tokenize(testCase.text)
+		if !reflect.DeepEqual(tokens, testCase.expectedTokens) {
+			t.Errorf("(Test \"%s\"), expected tokens %v, got %v", testCase.name, testCase.expectedTokens, tokens)
+		}
+		for _, token := range tokens {
+			if !testCase.options.Lowercase && testCase.text[token.Start:token.End] != token.Text {
+				t.Errorf("(Test \"%s\"), expected offsets %d-%d to hold %q, got %q", testCase.name, token.Start, token.End, token.Text, testCase.text[token.Start:token.End])
+			}
+		}
+	}
+}
+
+func TestWords(t *testing.T) {
+	words := Words(Tokenize("book that flight."))
+	expectedWords := []string{"book", "that", "flight", "."}
+	if !reflect.DeepEqual(words, expectedWords) {
+		t.Errorf("Expected words %v, got %v", expectedWords, words)
+	}
+}
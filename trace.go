@@ -0,0 +1,342 @@
+package gocky
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+)
+
+// PruneReason says why parses were dropped from a chart cell
+type PruneReason int
+
+const (
+	// PruneFeatures is reported for parses a production could have built, but whose features clashed
+	PruneFeatures PruneReason = iota + 1
+	// PruneDuplicate is reported for parses dropped by ParseOptions.Deduplicate
+	PruneDuplicate
+)
+
+// String returns the name of the reason
+func (r PruneReason) String() string {
+	switch r {
+	case PruneFeatures:
+		return "features"
+	case PruneDuplicate:
+		return "duplicate"
+	}
+	return fmt.Sprintf("PruneReason(%d)", int(r))
+}
+
+// Tracer is told about the work done by a parse, for debugging and monitoring
+//
+// Set ParseOptions.Tracer to trace a parse. Spans are word positions, with end not included in the span.
+// When ParseOptions.Workers is more than one, cells on the same diagonal are filled at once,
+// so the methods may be called from several goroutines and must be safe for that.
+// The parses passed to ConstituentCreated and CellCompleted are the nodes stored in the chart, and must not be modified.
+// TerminalLookup is given the parses as they were looked up, before duplicates are dropped from the cell.
+type Tracer interface {
+	// ParseStarted is called before any work is done on the words
+	ParseStarted(words []string)
+	// TerminalLookup is called with the parses found for the word, or multi-word nominal, spanning start to end
+	TerminalLookup(nominal string, start int, end int, parses []Parse)
+	// ConstituentCreated is called for each non-terminal parse stored in a cell, built from components split at split
+	// It is called once the cell is stored, so parses dropped as duplicates are only counted by Pruned.
+	ConstituentCreated(parse *Parse, start int, split int, end int)
+	// Pruned is called with the number of parses dropped from the cell spanning start to end, and why
+	Pruned(start int, end int, reason PruneReason, count int)
+	// CellCompleted is called once the cell spanning start to end holds all of its parses
+	CellCompleted(start int, end int, parses []Parse)
+	// ParseFinished is called with the complete parses, or the error that ended the parse
+	ParseFinished(words []string, parses []Parse, err error)
+}
+
+// traceCell reports a filled cell to the tracer, along with the parses deduplication dropped from it
+func (p *parser) traceCell(startIndex int, endIndex int, built int) {
+	cell := p.table[startIndex][endIndex]
+	if pruned := built - len(cell); pruned > 0 {
+		p.options.Tracer.Pruned(startIndex, endIndex, PruneDuplicate, pruned)
+	}
+	p.options.Tracer.CellCompleted(startIndex, endIndex, cell)
+}
+
+// traceTerminals reports the parses found for a nominal, along with the candidate productions whose features clashed
+func (p *parser) traceTerminals(nominal string, startIndex int, endIndex int, parses []Parse, candidates int) {
+	p.options.Tracer.TerminalLookup(nominal, startIndex, endIndex, parses)
+	if pruned := candidates - len(parses); pruned > 0 {
+		p.options.Tracer.Pruned(startIndex, endIndex, PruneFeatures, pruned)
+	}
+}
+
+// tracePhrases reports the parses found for each multi-word nominal starting at startIndex
+// The parses and their lengths are the ones phraseLookup returned.
+func (p *parser) tracePhrases(startIndex int, phraseParses []Parse, lengths []int) {
+	for _, phrase := range p.index.matchingPhrases(p.words[startIndex:]) {
+		parses := []Parse{}
+		for phraseIndex, length := range lengths {
+			if length == len(phrase.words) {
+				parses = append(parses, phraseParses[phraseIndex])
+			}
+		}
+		p.traceTerminals(strings.Join(phrase.words, " "), startIndex, startIndex+len(phrase.words), parses, len(phrase.nominals))
+	}
+}
+
+// traceSplit reports the productions whose features clashed while building parses from one split of a cell
+func (p *parser) traceSplit(startIndex int, splitIndex int, endIndex int, built []Parse) {
+	candidates := 0
+	for _, left := range p.table[startIndex][splitIndex] {
+		for _, right := range p.table[splitIndex][endIndex] {
+			candidates += len(p.index.nonterminals[componentKeys{left: left.production.key, right: right.production.key}])
+		}
+	}
+	if pruned := candidates - len(built); pruned > 0 {
+		p.options.Tracer.Pruned(startIndex, endIndex, PruneFeatures, pruned)
+	}
+}
+
+// traceConstituents reports the non-terminal parses stored in a cell, once the cell has been stored
+// built holds every parse built for the cell before duplicates were dropped, and splits the split of each, or 0 for a multi-word nominal.
+func (p *parser) traceConstituents(startIndex int, endIndex int, built []Parse, splits []int) {
+	kept := make([]int, len(built))
+	for builtIndex := range kept {
+		kept[builtIndex] = builtIndex
+	}
+	if p.options.Deduplicate {
+		kept = distinctParses(built)
+	}
+	cell := p.table[startIndex][endIndex]
+	for position, builtIndex := range kept {
+		if splits[builtIndex] > 0 {
+			p.options.Tracer.ConstituentCreated(&cell[position], startIndex, splits[builtIndex], endIndex)
+		}
+	}
+}
+
+// LogTracer is a Tracer that logs every event with log/slog
+// Parses finishing are logged at slog.LevelInfo, or slog.LevelWarn when they fail, and every other event at slog.LevelDebug.
+// Events below the logger's level cost little more than the check, so a LogTracer can be left in place in production.
+type LogTracer struct {
+	logger *slog.Logger
+}
+
+// NewLogTracer creates a LogTracer that logs to the logger, or to slog.Default when it is nil
+func NewLogTracer(logger *slog.Logger) *LogTracer {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &LogTracer{logger: logger}
+}
+
+// ParseStarted logs the words about to be parsed
+func (t *LogTracer) ParseStarted(words []string) {
+	t.log(slog.LevelDebug, "gocky: parse started", func() []slog.Attr {
+		return []slog.Attr{slog.String("words", strings.Join(words, " "))}
+	})
+}
+
+// TerminalLookup logs the keys found for a nominal
+func (t *LogTracer) TerminalLookup(nominal string, start int, end int, parses []Parse) {
+	t.log(slog.LevelDebug, "gocky: terminal lookup", func() []slog.Attr {
+		return []slog.Attr{slog.String("nominal", nominal), slog.Int("start", start), slog.Int("end", end), slog.Any("keys", parseKeys(parses))}
+	})
+}
+
+// ConstituentCreated logs the key and components of a new parse
+func (t *LogTracer) ConstituentCreated(parse *Parse, start int, split int, end int) {
+	t.log(slog.LevelDebug, "gocky: constituent created", func() []slog.Attr {
+		return []slog.Attr{
+			slog.String("key", parse.Key()),
+			slog.String("left", parse.left.Key()),
+			slog.String("right", parse.right.Key()),
+			slog.Int("start", start),
+			slog.Int("split", split),
+			slog.Int("end", end),
+		}
+	})
+}
+
+// Pruned logs the number of parses dropped from a cell
+func (t *LogTracer) Pruned(start int, end int, reason PruneReason, count int) {
+	t.log(slog.LevelDebug, "gocky: pruned", func() []slog.Attr {
+		return []slog.Attr{slog.Int("start", start), slog.Int("end", end), slog.String("reason", reason.String()), slog.Int("count", count)}
+	})
+}
+
+// CellCompleted logs the number of parses in a cell
+func (t *LogTracer) CellCompleted(start int, end int, parses []Parse) {
+	t.log(slog.LevelDebug, "gocky: cell completed", func() []slog.Attr {
+		return []slog.Attr{slog.Int("start", start), slog.Int("end", end), slog.Int("parses", len(parses))}
+	})
+}
+
+// ParseFinished logs the number of complete parses, or the error that ended the parse
+func (t *LogTracer) ParseFinished(words []string, parses []Parse, err error) {
+	if err != nil {
+		t.log(slog.LevelWarn, "gocky: parse failed", func() []slog.Attr {
+			return []slog.Attr{slog.Int("words", len(words)), slog.String("error", err.Error())}
+		})
+		return
+	}
+	t.log(slog.LevelInfo, "gocky: parse finished", func() []slog.Attr {
+		return []slog.Attr{slog.Int("words", len(words)), slog.Int("parses", len(parses))}
+	})
+}
+
+// log logs the message if the level is enabled, only building the attributes when it is
+func (t *LogTracer) log(level slog.Level, message string, attrs func() []slog.Attr) {
+	ctx := context.Background()
+	if t.logger.Enabled(ctx, level) {
+		t.logger.LogAttrs(ctx, level, message, attrs()...)
+	}
+}
+
+// parseKeys returns the key of each parse
+func parseKeys(parses []Parse) []string {
+	keys := make([]string, 0, len(parses))
+	for parseIndex := range parses {
+		keys = append(keys, parses[parseIndex].Key())
+	}
+	return keys
+}
+
+// CountingTracer is a Tracer that counts events, for exporting to a monitoring system
+// The counters only grow, and are safe to read with Counts while parses are running.
+type CountingTracer struct {
+	parses         int64
+	failures       int64
+	words          int64
+	terminalParses int64
+	constituents   int64
+	cells          int64
+	prunedFeatures int64
+	prunedDups     int64
+	completeParses int64
+}
+
+// TraceCounts is a snapshot of the counters of a CountingTracer
+type TraceCounts struct {
+	// Parses is the number of parses started
+	Parses int64
+	// Failures is the number of parses that ended with an error
+	Failures int64
+	// Words is the number of words parsed
+	Words int64
+	// TerminalParses is the number of parses found by terminal lookups
+	TerminalParses int64
+	// Constituents is the number of non-terminal parses built
+	Constituents int64
+	// Cells is the number of chart cells completed
+	Cells int64
+	// PrunedFeatures is the number of parses dropped because their features clashed
+	PrunedFeatures int64
+	// PrunedDuplicates is the number of parses dropped by deduplication
+	PrunedDuplicates int64
+	// CompleteParses is the number of parses spanning every word of their sentence
+	CompleteParses int64
+}
+
+// NewCountingTracer creates a CountingTracer with every counter at zero
+func NewCountingTracer() *CountingTracer {
+	return &CountingTracer{}
+}
+
+// Counts returns the current value of every counter
+func (t *CountingTracer) Counts() TraceCounts {
+	return TraceCounts{
+		Parses:           atomic.LoadInt64(&t.parses),
+		Failures:         atomic.LoadInt64(&t.failures),
+		Words:            atomic.LoadInt64(&t.words),
+		TerminalParses:   atomic.LoadInt64(&t.terminalParses),
+		Constituents:     atomic.LoadInt64(&t.constituents),
+		Cells:            atomic.LoadInt64(&t.cells),
+		PrunedFeatures:   atomic.LoadInt64(&t.prunedFeatures),
+		PrunedDuplicates: atomic.LoadInt64(&t.prunedDups),
+		CompleteParses:   atomic.LoadInt64(&t.completeParses),
+	}
+}
+
+// ParseStarted counts a parse and its words
+func (t *CountingTracer) ParseStarted(words []string) {
+	atomic.AddInt64(&t.parses, 1)
+	atomic.AddInt64(&t.words, int64(len(words)))
+}
+
+// TerminalLookup counts the parses found for a nominal
+func (t *CountingTracer) TerminalLookup(nominal string, start int, end int, parses []Parse) {
+	atomic.AddInt64(&t.terminalParses, int64(len(parses)))
+}
+
+// ConstituentCreated counts a non-terminal parse
+func (t *CountingTracer) ConstituentCreated(parse *Parse, start int, split int, end int) {
+	atomic.AddInt64(&t.constituents, 1)
+}
+
+// Pruned counts dropped parses by reason
+func (t *CountingTracer) Pruned(start int, end int, reason PruneReason, count int) {
+	switch reason {
+	case PruneFeatures:
+		atomic.AddInt64(&t.prunedFeatures, int64(count))
+	case PruneDuplicate:
+		atomic.AddInt64(&t.prunedDups, int64(count))
+	}
+}
+
+// CellCompleted counts a chart cell
+func (t *CountingTracer) CellCompleted(start int, end int, parses []Parse) {
+	atomic.AddInt64(&t.cells, 1)
+}
+
+// ParseFinished counts the complete parses, or a failure
+func (t *CountingTracer) ParseFinished(words []string, parses []Parse, err error) {
+	if err != nil {
+		atomic.AddInt64(&t.failures, 1)
+		return
+	}
+	atomic.AddInt64(&t.completeParses, int64(len(parses)))
+}
+
+// MultiTracer returns a Tracer that passes every event to each of the tracers in turn
+func MultiTracer(tracers ...Tracer) Tracer {
+	return multiTracer(append([]Tracer{}, tracers...))
+}
+
+// multiTracer is the Tracer returned by MultiTracer
+type multiTracer []Tracer
+
+func (m multiTracer) ParseStarted(words []string) {
+	for _, tracer := range m {
+		tracer.ParseStarted(words)
+	}
+}
+
+func (m multiTracer) TerminalLookup(nominal string, start int, end int, parses []Parse) {
+	for _, tracer := range m {
+		tracer.TerminalLookup(nominal, start, end, parses)
+	}
+}
+
+func (m multiTracer) ConstituentCreated(parse *Parse, start int, split int, end int) {
+	for _, tracer := range m {
+		tracer.ConstituentCreated(parse, start, split, end)
+	}
+}
+
+func (m multiTracer) Pruned(start int, end int, reason PruneReason, count int) {
+	for _, tracer := range m {
+		tracer.Pruned(start, end, reason, count)
+	}
+}
+
+func (m multiTracer) CellCompleted(start int, end int, parses []Parse) {
+	for _, tracer := range m {
+		tracer.CellCompleted(start, end, parses)
+	}
+}
+
+func (m multiTracer) ParseFinished(words []string, parses []Parse, err error) {
+	for _, tracer := range m {
+		tracer.ParseFinished(words, parses, err)
+	}
+}
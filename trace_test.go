@@ -0,0 +1,241 @@
+package gocky
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// recordingTracer records every event as a line of text
+type recordingTracer struct {
+	mutex  sync.Mutex
+	events []string
+}
+
+func (r *recordingTracer) record(format string, args ...interface{}) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.events = append(r.events, fmt.Sprintf(format, args...))
+}
+
+func (r *recordingTracer) ParseStarted(words []string) {
+	r.record("started %v", words)
+}
+
+func (r *recordingTracer) TerminalLookup(nominal string, start int, end int, parses []Parse) {
+	r.record("lookup %q %d-%d %v", nominal, start, end, parseKeys(parses))
+}
+
+func (r *recordingTracer) ConstituentCreated(parse *Parse, start int, split int, end int) {
+	r.record("created %s %d-%d-%d", parse.Key(), start, split, end)
+}
+
+func (r *recordingTracer) Pruned(start int, end int, reason PruneReason, count int) {
+	r.record("pruned %d-%d %s %d", start, end, reason, count)
+}
+
+func (r *recordingTracer) CellCompleted(start int, end int, parses []Parse) {
+	r.record("cell %d-%d %d", start, end, len(parses))
+}
+
+func (r *recordingTracer) ParseFinished(words []string, parses []Parse, err error) {
+	r.record("finished %d %v", len(parses), err)
+}
+
+func TestTracerEvents(t *testing.T) {
+	type test struct {
+		name           string
+		words          []string
+		grammar        Grammar
+		options        ParseOptions
+		expectedEvents []string
+	}
+
+	testCases := []test{
+		{
+			name:    "multi-word nominals",
+			words:   []string{"look", "up", "New", "York"},
+			grammar: lookUpNewYork(),
+			expectedEvents: []string{
+				"started [look up New York]",
+				"lookup \"look\" 0-1 [V]", "cell 0-1 1",
+				"lookup \"up\" 1-2 [P]", "cell 1-2 1",
+				"lookup \"New\" 2-3 [J]", "cell 2-3 1",
+				"lookup \"York\" 3-4 [N]", "cell 3-4 1",
+				"lookup \"look up\" 0-2 [V]",
+				"lookup \"New York\" 2-4 [N]",
+				"cell 0-2 1", "cell 1-3 0", "created N 2-3-4", "cell 2-4 2",
+				"cell 0-3 0", "created PP 1-2-4", "created PP 1-2-4", "cell 1-4 2",
+				"created VP 0-1-4", "created VP 0-1-4", "created VP 0-2-4", "created VP 0-2-4", "cell 0-4 4",
+				"finished 4 <nil>",
+			},
+		},
+		{
+			name:    "features",
+			words:   []string{"the", "dogs", "barks"},
+			grammar: agreement(),
+			expectedEvents: []string{
+				"started [the dogs barks]",
+				"lookup \"the\" 0-1 [DT]", "cell 0-1 1",
+				"lookup \"dogs\" 1-2 [N]", "cell 1-2 1",
+				"lookup \"barks\" 2-3 [V]", "cell 2-3 1",
+				"created NP 0-1-2", "cell 0-2 1", "cell 1-3 0",
+				"pruned 0-3 features 1", "cell 0-3 0",
+				"finished 0 <nil>",
+			},
+		},
+		{
+			name:    "duplicates",
+			words:   []string{"dog"},
+			grammar: Grammar{TerminalProduction("N", []string{"dog"}), TerminalProduction("N", []string{"dog"})},
+			options: ParseOptions{Deduplicate: true},
+			expectedEvents: []string{
+				"started [dog]",
+				"lookup \"dog\" 0-1 [N N]", "pruned 0-1 duplicate 1", "cell 0-1 1",
+				"finished 1 <nil>",
+			},
+		},
+		{
+			name:    "limit",
+			words:   []string{"look", "up", "New", "York"},
+			grammar: lookUpNewYork(),
+			options: ParseOptions{MaxWords: 3},
+			expectedEvents: []string{
+				"started [look up New York]",
+				"finished 0 gocky: parse exceeded max words (3)",
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		tracer := &recordingTracer{}
+		testCase.options.Tracer = tracer
+		ParsesContext(context.Background(), testCase.words, testCase.grammar, testCase.options)
+		if !reflect.DeepEqual(tracer.events, testCase.expectedEvents) {
+			t.Errorf("(Test \"%s\"), expected events\n%s\ngot\n%s", testCase.name, strings.Join(testCase.expectedEvents, "\n"), strings.Join(tracer.events, "\n"))
+		}
+	}
+}
+
+// chartTracer checks that every constituent it is told about is a node of the completed cell
+type chartTracer struct {
+	recordingTracer
+	t       *testing.T
+	created []*Parse
+}
+
+func (c *chartTracer) ConstituentCreated(parse *Parse, start int, split int, end int) {
+	c.created = append(c.created, parse)
+}
+
+func (c *chartTracer) CellCompleted(start int, end int, parses []Parse) {
+	for _, created := range c.created {
+		found := false
+		for parseIndex := range parses {
+			found = found || created == &parses[parseIndex]
+		}
+		if !found {
+			c.t.Errorf("Expected the constituent %s to be a node of cell %d-%d", created.String(), start, end)
+		}
+	}
+	c.created = nil
+}
+
+func TestTracerChartNodes(t *testing.T) {
+	redundantPanda := append(panda(),
+		Production{key: "N", nominals: []string{"panda", "leaves"}},
+		Production{key: "DN0", left: "DT", right: "N"},
+	)
+	words := []string{"the", "panda", "eats", "shoots", "and", "leaves"}
+	for _, deduplicate := range []bool{false, true} {
+		tracer := &chartTracer{t: t}
+		parses, err := ParsesContext(context.Background(), words, redundantPanda, ParseOptions{Deduplicate: deduplicate, Tracer: tracer})
+		if err != nil || len(parses) == 0 {
+			t.Fatalf("(Deduplicate %v), expected parses, got %d and %v", deduplicate, len(parses), err)
+		}
+	}
+}
+
+func TestCountingTracer(t *testing.T) {
+	tracer := NewCountingTracer()
+	options := ParseOptions{Tracer: tracer, Workers: 4}
+	ParsesContext(context.Background(), []string{"look", "up", "New", "York"}, lookUpNewYork(), options)
+	ParsesContext(context.Background(), []string{"the", "dogs", "barks"}, agreement(), options)
+	ParsesContext(context.Background(), []string{"look", "up"}, lookUpNewYork(), ParseOptions{Tracer: tracer, MaxWords: 1})
+
+	expected := TraceCounts{
+		Parses:         3,
+		Failures:       1,
+		Words:          9,
+		TerminalParses: 9,
+		Constituents:   8,
+		Cells:          16,
+		PrunedFeatures: 1,
+		CompleteParses: 4,
+	}
+	if counts := tracer.Counts(); counts != expected {
+		t.Errorf("Expected counts %+v, got %+v", expected, counts)
+	}
+}
+
+func TestLogTracer(t *testing.T) {
+	type test struct {
+		name       string
+		level      slog.Level
+		words      []string
+		expected   []string
+		unexpected []string
+	}
+
+	testCases := []test{
+		{
+			name:     "debug",
+			level:    slog.LevelDebug,
+			words:    []string{"look", "up", "New", "York"},
+			expected: []string{"msg=\"gocky: constituent created\" key=VP left=V right=PP start=0 split=1 end=4", "msg=\"gocky: parse finished\" words=4 parses=4"},
+		},
+		{
+			name:       "info",
+			level:      slog.LevelInfo,
+			words:      []string{"look", "up", "New", "York"},
+			expected:   []string{"level=INFO msg=\"gocky: parse finished\""},
+			unexpected: []string{"constituent created", "terminal lookup"},
+		},
+		{
+			name:     "failure",
+			level:    slog.LevelInfo,
+			words:    []string{"look", "up", "New", "York", "up"},
+			expected: []string{"level=WARN msg=\"gocky: parse failed\" words=5 error=\"gocky: parse exceeded max words (4)\""},
+		},
+	}
+
+	for _, testCase := range testCases {
+		buffer := &bytes.Buffer{}
+		logger := slog.New(slog.NewTextHandler(buffer, &slog.HandlerOptions{Level: testCase.level}))
+		options := ParseOptions{Tracer: NewLogTracer(logger), MaxWords: 4}
+		ParsesContext(context.Background(), testCase.words, lookUpNewYork(), options)
+		for _, expected := range testCase.expected {
+			if !strings.Contains(buffer.String(), expected) {
+				t.Errorf("(Test \"%s\"), expected the log to contain %q, got %q", testCase.name, expected, buffer.String())
+			}
+		}
+		for _, unexpected := range testCase.unexpected {
+			if strings.Contains(buffer.String(), unexpected) {
+				t.Errorf("(Test \"%s\"), expected the log not to contain %q", testCase.name, unexpected)
+			}
+		}
+	}
+}
+
+func TestMultiTracer(t *testing.T) {
+	first, second := NewCountingTracer(), NewCountingTracer()
+	ParsesContext(context.Background(), []string{"look", "York"}, lookUpNewYork(), ParseOptions{Tracer: MultiTracer(first, second)})
+	if first.Counts() != second.Counts() || first.Counts().CompleteParses != 1 {
+		t.Errorf("Expected both tracers to count 1 complete parse, got %+v and %+v", first.Counts(), second.Counts())
+	}
+}
@@ -0,0 +1,443 @@
+package gocky
+
+import (
+	"errors"
+	"math"
+	"strings"
+)
+
+// ErrNoTrainingParses is returned by Train when none of the training sentences can be parsed by the grammar
+var ErrNoTrainingParses = errors.New("gocky: no training sentence could be parsed")
+
+// TrainOptions controls inside-outside training
+type TrainOptions struct {
+	// Iterations is the number of expectation-maximisation rounds to run
+	Iterations int
+	// StartKeys restricts training to parses generated from these production keys, like MatchingParses
+	// When empty, a parse from any key counts, like Parses
+	StartKeys []string
+}
+
+// TrainResult holds the outcome of Train
+type TrainResult struct {
+	// Grammar is the re-estimated, weighted grammar
+	Grammar Grammar
+	// LogLikelihoods holds the corpus log-likelihood measured at the start of each iteration
+	LogLikelihoods []float64
+	// Skipped counts the training sentences the grammar could not parse
+	Skipped int
+}
+
+// Train re-estimates the probabilities of a grammar from unannotated sentences using the inside-outside algorithm.
+// https://en.wikipedia.org/wiki/Inside%E2%80%93outside_algorithm
+//
+// Weighted productions start from their own probabilities, unweighted productions start from a uniform share of their key.
+// The productions of the returned grammar are in the same order as the provided grammar.
+// Sentences the grammar cannot parse are skipped, and keys that are never used keep their starting probabilities.
+// Feature constraints are checked like the parser checks them, so derivations it would reject get no probability.
+func Train(grammar Grammar, corpus [][]string, options TrainOptions) (TrainResult, error) {
+	model := newTrainingModel(grammar, options.StartKeys)
+	result := TrainResult{LogLikelihoods: []float64{}}
+	for iteration := 0; iteration < options.Iterations; iteration++ {
+		counts := model.newCounts()
+		logLikelihood := 0.0
+		skipped := 0
+		for _, words := range corpus {
+			sentenceScore, ok := model.expectedCounts(words, counts)
+			if !ok {
+				skipped++
+				continue
+			}
+			logLikelihood += sentenceScore
+		}
+		if skipped == len(corpus) {
+			return result, ErrNoTrainingParses
+		}
+		result.LogLikelihoods = append(result.LogLikelihoods, logLikelihood)
+		result.Skipped = skipped
+		model.maximise(counts)
+	}
+	result.Grammar = model.grammar()
+	return result, nil
+}
+
+// binaryRule is a non-terminal production, with its keys replaced by key identifiers
+type binaryRule struct {
+	production int
+	key        int
+	left       int
+	right      int
+}
+
+// lexicalRule is a single nominal of a terminal production, covering one or more words
+// The category is -1 when the nominal's features clash with the production's, because the parser never builds it.
+type lexicalRule struct {
+	production int
+	nominal    int
+	key        int
+	category   int
+	words      int
+}
+
+// trainingCategory is a key together with the canonical features of a node
+// Inside and outside scores are kept per category, so that derivations the parser rejects for clashing features get no probability.
+type trainingCategory struct {
+	key      int
+	features string
+}
+
+// trainingCombination is a binary rule applied to a left and a right category
+type trainingCombination struct {
+	rule  int
+	left  int
+	right int
+}
+
+// trainingModel holds a grammar in the shape needed for inside-outside training
+type trainingModel struct {
+	source       Grammar
+	keys         map[string]int
+	startKeys    []bool
+	binaryRules  []binaryRule
+	rulesByLeft  map[int][]int
+	lexical      []lexicalRule
+	lexicon      map[string][]int
+	maxWords     int
+	categories   map[trainingCategory]int
+	categoryKeys []int
+	features     []Features
+	combinations map[trainingCombination]int
+	binaryProbs  []float64
+	lexicalProb  []float64
+}
+
+// trainingCounts holds the expected number of uses of each rule
+type trainingCounts struct {
+	binary  []float64
+	lexical []float64
+}
+
+// newTrainingModel builds a training model with the starting probabilities of the grammar
+func newTrainingModel(grammar Grammar, startKeys []string) *trainingModel {
+	model := &trainingModel{
+		source:       grammar,
+		keys:         map[string]int{},
+		rulesByLeft:  map[int][]int{},
+		lexicon:      map[string][]int{},
+		categories:   map[trainingCategory]int{},
+		combinations: map[trainingCombination]int{},
+	}
+	alternatives := map[string]int{}
+	for productionIndex, production := range grammar {
+		key := model.keyID(production.key)
+		if len(production.left) > 0 || len(production.right) > 0 {
+			rule := binaryRule{
+				production: productionIndex,
+				key:        key,
+				left:       model.keyID(production.left),
+				right:      model.keyID(production.right),
+			}
+			model.rulesByLeft[rule.left] = append(model.rulesByLeft[rule.left], len(model.binaryRules))
+			model.binaryRules = append(model.binaryRules, rule)
+			alternatives[production.key]++
+			continue
+		}
+		for nominalIndex, nominal := range production.nominals {
+			if indexOf(production.nominals, nominal) != nominalIndex {
+				continue
+			}
+			rule := lexicalRule{production: productionIndex, nominal: nominalIndex, key: key, category: -1, words: len(nominalWords(nominal))}
+			if parse, ok := newTerminalParse(&grammar[productionIndex], nominal); ok {
+				rule.category = model.categoryID(key, parse.features)
+				lexiconKey := strings.Join(nominalWords(nominal), " ")
+				model.lexicon[lexiconKey] = append(model.lexicon[lexiconKey], len(model.lexical))
+			}
+			model.lexical = append(model.lexical, rule)
+			if rule.words > model.maxWords {
+				model.maxWords = rule.words
+			}
+			alternatives[production.key]++
+		}
+	}
+
+	model.binaryProbs = make([]float64, len(model.binaryRules))
+	for ruleIndex, rule := range model.binaryRules {
+		production := grammar[rule.production]
+		if production.weighted {
+			model.binaryProbs[ruleIndex] = production.probability
+		} else {
+			model.binaryProbs[ruleIndex] = 1 / float64(alternatives[production.key])
+		}
+	}
+	model.lexicalProb = make([]float64, len(model.lexical))
+	for ruleIndex, rule := range model.lexical {
+		production := grammar[rule.production]
+		if production.weighted {
+			model.lexicalProb[ruleIndex] = production.nominalProbabilityAt(rule.nominal)
+		} else {
+			model.lexicalProb[ruleIndex] = 1 / float64(alternatives[production.key])
+		}
+	}
+
+	model.startKeys = make([]bool, len(model.keys))
+	for key, id := range model.keys {
+		model.startKeys[id] = len(startKeys) == 0 || contains(startKeys, key)
+	}
+	return model
+}
+
+// keyID returns the identifier for a production key, assigning a new one if needed
+func (m *trainingModel) keyID(key string) int {
+	id, ok := m.keys[key]
+	if !ok {
+		id = len(m.keys)
+		m.keys[key] = id
+	}
+	return id
+}
+
+// categoryID returns the identifier for a key with features, assigning a new one if needed
+func (m *trainingModel) categoryID(key int, features Features) int {
+	category := trainingCategory{key: key, features: features.canonical()}
+	id, ok := m.categories[category]
+	if !ok {
+		id = len(m.categoryKeys)
+		m.categories[category] = id
+		m.categoryKeys = append(m.categoryKeys, key)
+		m.features = append(m.features, features)
+	}
+	return id
+}
+
+// combine returns the category built by a binary rule from a left and a right category, or -1 if their features clash
+// The parent is built with newNonterminalParse, so agreement and head features are checked exactly as the parser checks them.
+func (m *trainingModel) combine(ruleIndex int, left int, right int) int {
+	combination := trainingCombination{rule: ruleIndex, left: left, right: right}
+	if parent, ok := m.combinations[combination]; ok {
+		return parent
+	}
+	parent := -1
+	rule := m.binaryRules[ruleIndex]
+	leftParse, rightParse := &Parse{features: m.features[left]}, &Parse{features: m.features[right]}
+	if parse, ok := newNonterminalParse(&m.source[rule.production], leftParse, rightParse); ok {
+		parent = m.categoryID(rule.key, parse.features)
+	}
+	m.combinations[combination] = parent
+	return parent
+}
+
+// newCounts creates zeroed expected counts for every rule in the model
+func (m *trainingModel) newCounts() trainingCounts {
+	return trainingCounts{
+		binary:  make([]float64, len(m.binaryRules)),
+		lexical: make([]float64, len(m.lexical)),
+	}
+}
+
+// trainingCell holds the log score of each category over a span, in the order the categories were first scored
+type trainingCell struct {
+	categories []int
+	scores     []float64
+	positions  map[int]int
+}
+
+// add adds a log score to the category's score
+func (c *trainingCell) add(category int, score float64) {
+	if c.positions == nil {
+		c.positions = map[int]int{}
+	}
+	position, ok := c.positions[category]
+	if !ok {
+		c.positions[category] = len(c.categories)
+		c.categories = append(c.categories, category)
+		c.scores = append(c.scores, score)
+		return
+	}
+	c.scores[position] = logAdd(c.scores[position], score)
+}
+
+// score returns the log score of the category, reporting false if it has none
+func (c *trainingCell) score(category int) (float64, bool) {
+	position, ok := c.positions[category]
+	if !ok {
+		return 0, false
+	}
+	return c.scores[position], true
+}
+
+// splitVisitor is called for every binary rule that builds a category over a span from categories over its two parts
+type splitVisitor func(startIndex int, splitIndex int, endIndex int, ruleIndex int, parent int, left int, right int)
+
+// forEachSplit calls visit for every way the inside chart builds a category from two smaller spans, shortest spans first
+func (m *trainingModel) forEachSplit(inside [][]trainingCell, spanLength int, visit splitVisitor) {
+	length := len(inside) - 1
+	for startIndex := 0; startIndex+spanLength <= length; startIndex++ {
+		endIndex := startIndex + spanLength
+		for splitIndex := startIndex + 1; splitIndex < endIndex; splitIndex++ {
+			leftCell, rightCell := &inside[startIndex][splitIndex], &inside[splitIndex][endIndex]
+			for _, left := range leftCell.categories {
+				for _, ruleIndex := range m.rulesByLeft[m.categoryKeys[left]] {
+					if m.binaryProbs[ruleIndex] == 0 {
+						continue
+					}
+					for _, right := range rightCell.categories {
+						if m.categoryKeys[right] != m.binaryRules[ruleIndex].right {
+							continue
+						}
+						if parent := m.combine(ruleIndex, left, right); parent >= 0 {
+							visit(startIndex, splitIndex, endIndex, ruleIndex, parent, left, right)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// expectedCounts adds the expected rule uses for a sentence to counts and returns the log probability of the sentence
+// Scores are kept as logarithms so that long sentences do not underflow, and false is returned when the sentence has no parse.
+func (m *trainingModel) expectedCounts(words []string, counts trainingCounts) (float64, bool) {
+	length := len(words)
+	if length == 0 {
+		return 0, false
+	}
+	inside := newTrainingChart(length)
+	outside := newTrainingChart(length)
+
+	m.forEachLexical(words, func(startIndex int, ruleIndex int) {
+		rule := m.lexical[ruleIndex]
+		if m.lexicalProb[ruleIndex] > 0 {
+			inside[startIndex][startIndex+rule.words].add(rule.category, math.Log(m.lexicalProb[ruleIndex]))
+		}
+	})
+	for spanLength := 2; spanLength <= length; spanLength++ {
+		m.forEachSplit(inside, spanLength, func(startIndex int, splitIndex int, endIndex int, ruleIndex int, parent int, left int, right int) {
+			leftScore, _ := inside[startIndex][splitIndex].score(left)
+			rightScore, _ := inside[splitIndex][endIndex].score(right)
+			inside[startIndex][endIndex].add(parent, math.Log(m.binaryProbs[ruleIndex])+leftScore+rightScore)
+		})
+	}
+
+	sentenceScore := math.Inf(-1)
+	top := &inside[0][length]
+	for position, category := range top.categories {
+		if m.startKeys[m.categoryKeys[category]] {
+			sentenceScore = logAdd(sentenceScore, top.scores[position])
+			outside[0][length].add(category, 0)
+		}
+	}
+	if math.IsInf(sentenceScore, -1) {
+		return 0, false
+	}
+
+	for spanLength := length; spanLength >= 2; spanLength-- {
+		m.forEachSplit(inside, spanLength, func(startIndex int, splitIndex int, endIndex int, ruleIndex int, parent int, left int, right int) {
+			parentScore, ok := outside[startIndex][endIndex].score(parent)
+			if !ok {
+				return
+			}
+			leftScore, _ := inside[startIndex][splitIndex].score(left)
+			rightScore, _ := inside[splitIndex][endIndex].score(right)
+			probability := math.Log(m.binaryProbs[ruleIndex])
+			outside[startIndex][splitIndex].add(left, probability+parentScore+rightScore)
+			outside[splitIndex][endIndex].add(right, probability+parentScore+leftScore)
+			counts.binary[ruleIndex] += math.Exp(probability + parentScore + leftScore + rightScore - sentenceScore)
+		})
+	}
+	m.forEachLexical(words, func(startIndex int, ruleIndex int) {
+		rule := m.lexical[ruleIndex]
+		parentScore, ok := outside[startIndex][startIndex+rule.words].score(rule.category)
+		if ok && m.lexicalProb[ruleIndex] > 0 {
+			counts.lexical[ruleIndex] += math.Exp(parentScore + math.Log(m.lexicalProb[ruleIndex]) - sentenceScore)
+		}
+	})
+	return sentenceScore, true
+}
+
+// forEachLexical calls visit for every lexical rule matching the words starting at each position, including multi-word nominals
+func (m *trainingModel) forEachLexical(words []string, visit func(startIndex int, ruleIndex int)) {
+	for startIndex := range words {
+		for length := 1; length <= m.maxWords && startIndex+length <= len(words); length++ {
+			for _, ruleIndex := range m.lexicon[strings.Join(words[startIndex:startIndex+length], " ")] {
+				visit(startIndex, ruleIndex)
+			}
+		}
+	}
+}
+
+// newTrainingChart creates an inside or outside chart with a cell for every span
+func newTrainingChart(length int) [][]trainingCell {
+	chart := make([][]trainingCell, length+1)
+	for startIndex := range chart {
+		chart[startIndex] = make([]trainingCell, length+1)
+	}
+	return chart
+}
+
+// logAdd returns the logarithm of the sum of two numbers given as logarithms
+func logAdd(a float64, b float64) float64 {
+	if math.IsInf(a, -1) {
+		return b
+	}
+	if math.IsInf(b, -1) {
+		return a
+	}
+	if a < b {
+		a, b = b, a
+	}
+	return a + math.Log1p(math.Exp(b-a))
+}
+
+// maximise replaces the rule probabilities with their share of the expected counts for their key
+// Keys with no expected uses keep their probabilities.
+func (m *trainingModel) maximise(counts trainingCounts) {
+	totals := make([]float64, len(m.keys))
+	for ruleIndex, rule := range m.binaryRules {
+		totals[rule.key] += counts.binary[ruleIndex]
+	}
+	for ruleIndex, rule := range m.lexical {
+		totals[rule.key] += counts.lexical[ruleIndex]
+	}
+	for ruleIndex, rule := range m.binaryRules {
+		if totals[rule.key] > 0 {
+			m.binaryProbs[ruleIndex] = counts.binary[ruleIndex] / totals[rule.key]
+		}
+	}
+	for ruleIndex, rule := range m.lexical {
+		if totals[rule.key] > 0 {
+			m.lexicalProb[ruleIndex] = counts.lexical[ruleIndex] / totals[rule.key]
+		}
+	}
+}
+
+// grammar writes the model's probabilities back onto a copy of the source grammar
+func (m *trainingModel) grammar() Grammar {
+	grammar := make(Grammar, len(m.source))
+	for productionIndex, production := range m.source {
+		production.weighted = true
+		production.nominalProbabilities = make([]float64, len(production.nominals))
+		grammar[productionIndex] = production
+	}
+	for ruleIndex, rule := range m.binaryRules {
+		grammar[rule.production].probability = m.binaryProbs[ruleIndex]
+	}
+	for ruleIndex, rule := range m.lexical {
+		production := &grammar[rule.production]
+		for nominalIndex, nominal := range production.nominals {
+			if nominal == production.nominals[rule.nominal] {
+				production.nominalProbabilities[nominalIndex] = m.lexicalProb[ruleIndex]
+			}
+		}
+	}
+	return grammar
+}
+
+// indexOf returns the position of the first occurrence of value in searchSpace, or -1
+func indexOf(searchSpace []string, value string) int {
+	for index, searchSpaceValue := range searchSpace {
+		if value == searchSpaceValue {
+			return index
+		}
+	}
+	return -1
+}
@@ -0,0 +1,166 @@
+package gocky
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestTrainRelativeFrequency(t *testing.T) {
+	grammar := Grammar{
+		TerminalProduction("A", []string{"a", "b"}),
+		NonterminalProduction("S", "A", "A"),
+	}
+	corpus := [][]string{{"a", "a"}, {"a", "b"}, {"a", "a"}}
+
+	result, err := Train(grammar, corpus, TrainOptions{Iterations: 2, StartKeys: []string{"S"}})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	// An unambiguous grammar converges to relative frequencies after a single iteration
+	expectedProbabilities := map[string]float64{"a": 5.0 / 6.0, "b": 1.0 / 6.0}
+	for nominal, expectedProbability := range expectedProbabilities {
+		actualProbability := result.Grammar[0].NominalProbability(nominal)
+		if math.Abs(expectedProbability-actualProbability) > 1e-9 {
+			t.Errorf("Nominal \"%s\" expected probability %f, got %f", nominal, expectedProbability, actualProbability)
+		}
+	}
+	if result.Grammar[1].Probability() != 1 {
+		t.Errorf("Production S expected probability 1, got %f", result.Grammar[1].Probability())
+	}
+	if len(result.LogLikelihoods) != 2 {
+		t.Fatalf("Expected 2 log likelihoods, got %d", len(result.LogLikelihoods))
+	}
+	expectedLogLikelihood := 5*math.Log(5.0/6.0) + math.Log(1.0/6.0)
+	if math.Abs(expectedLogLikelihood-result.LogLikelihoods[1]) > 1e-9 {
+		t.Errorf("Expected log likelihood %f, got %f", expectedLogLikelihood, result.LogLikelihoods[1])
+	}
+}
+
+func TestTrainAmbiguous(t *testing.T) {
+	corpus := [][]string{
+		{"the", "panda", "eats", "shoots", "and", "leaves"},
+		{"the", "panda", "eats"},
+		{"the", "panda", "shoots", "and", "leaves"},
+		{"the", "panda", "eats", "and"},
+	}
+
+	result, err := Train(panda(), corpus, TrainOptions{Iterations: 5})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if result.Skipped != 1 {
+		t.Errorf("Expected 1 skipped sentence, got %d", result.Skipped)
+	}
+	for iteration := 1; iteration < len(result.LogLikelihoods); iteration++ {
+		if result.LogLikelihoods[iteration] < result.LogLikelihoods[iteration-1]-1e-9 {
+			t.Errorf("Log likelihood decreased from %f to %f", result.LogLikelihoods[iteration-1], result.LogLikelihoods[iteration])
+		}
+	}
+
+	totals := map[string]float64{}
+	for _, production := range result.Grammar {
+		if !production.Weighted() {
+			t.Errorf("Production %s should be weighted", production.key)
+		}
+		if len(production.nominals) == 0 {
+			totals[production.key] += production.Probability()
+		}
+		for _, nominal := range production.nominals {
+			totals[production.key] += production.NominalProbability(nominal)
+		}
+	}
+	for _, key := range []string{"N", "V", "DT", "S2", "S3"} {
+		if math.Abs(totals[key]-1) > 1e-9 {
+			t.Errorf("Probabilities for key %s should sum to 1, got %f", key, totals[key])
+		}
+	}
+}
+
+func TestTrainNoParses(t *testing.T) {
+	_, err := Train(panda(), [][]string{{"panda", "the"}}, TrainOptions{Iterations: 1})
+	if !errors.Is(err, ErrNoTrainingParses) {
+		t.Errorf("Expected ErrNoTrainingParses, got %v", err)
+	}
+}
+
+func TestTrainMultiWordNominals(t *testing.T) {
+	grammar := Grammar{
+		TerminalProduction("N", []string{"New York", "York"}),
+		TerminalProduction("J", []string{"New"}),
+		TerminalProduction("V", []string{"sleeps"}),
+		NonterminalProduction("N", "J", "N"),
+		NonterminalProduction("S", "N", "V"),
+	}
+	corpus := [][]string{{"New", "York", "sleeps"}, {"York", "sleeps"}}
+
+	result, err := Train(grammar, corpus, TrainOptions{Iterations: 10, StartKeys: []string{"S"}})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if result.Skipped != 0 {
+		t.Errorf("Expected no skipped sentences, got %d", result.Skipped)
+	}
+	if probability := result.Grammar[0].NominalProbability("New York"); probability <= 0 {
+		t.Errorf("Expected the multi-word nominal to be counted, got probability %f", probability)
+	}
+	total := result.Grammar[0].NominalProbability("New York") + result.Grammar[0].NominalProbability("York") + result.Grammar[3].Probability()
+	if math.Abs(total-1) > 1e-9 {
+		t.Errorf("Probabilities for key N should sum to 1, got %f", total)
+	}
+}
+
+func TestTrainAgreement(t *testing.T) {
+	agreeing := [][]string{{"the", "dogs", "bark"}}
+	expected, err := Train(agreement(), agreeing, TrainOptions{Iterations: 1, StartKeys: []string{"S"}})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	// "the dogs barks" has no parse, because the plural noun phrase clashes with the singular verb
+	corpus := [][]string{{"the", "dogs", "bark"}, {"the", "dogs", "barks"}}
+	result, err := Train(agreement(), corpus, TrainOptions{Iterations: 1, StartKeys: []string{"S"}})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if result.Skipped != 1 {
+		t.Errorf("Expected the clashing sentence to be skipped, got %d skipped", result.Skipped)
+	}
+	if math.Abs(expected.LogLikelihoods[0]-result.LogLikelihoods[0]) > 1e-9 {
+		t.Errorf("Expected log likelihood %f, got %f", expected.LogLikelihoods[0], result.LogLikelihoods[0])
+	}
+	if probability := result.Grammar[2].NominalProbability("barks"); probability != 0 {
+		t.Errorf("Expected \"barks\" to be unused, got probability %f", probability)
+	}
+
+	_, err = Train(agreement(), [][]string{{"a", "dogs", "bark"}}, TrainOptions{Iterations: 1, StartKeys: []string{"S"}})
+	if !errors.Is(err, ErrNoTrainingParses) {
+		t.Errorf("Expected ErrNoTrainingParses, got %v", err)
+	}
+}
+
+func TestTrainLongSentence(t *testing.T) {
+	grammar := Grammar{
+		WeightedTerminalProduction("A", []string{"a", "b"}, []float64{0.001, 0.999}),
+		NonterminalProduction("S", "A", "S"),
+		NonterminalProduction("S", "A", "A"),
+	}
+	words := make([]string, 200)
+	for wordIndex := range words {
+		words[wordIndex] = "a"
+	}
+
+	// The sentence probability is far below the smallest float64, so it can only be measured as a logarithm
+	result, err := Train(grammar, [][]string{words}, TrainOptions{Iterations: 1, StartKeys: []string{"S"}})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	expectedLogLikelihood := 199*math.Log(0.5) + 200*math.Log(0.001)
+	if result.Skipped != 0 || math.Abs(expectedLogLikelihood-result.LogLikelihoods[0]) > 1e-6 {
+		t.Errorf("Expected log likelihood %f with nothing skipped, got %f with %d skipped", expectedLogLikelihood, result.LogLikelihoods[0], result.Skipped)
+	}
+	if probability := result.Grammar[0].NominalProbability("a"); math.Abs(probability-1) > 1e-9 {
+		t.Errorf("Expected \"a\" to take all the probability, got %f", probability)
+	}
+}
@@ -0,0 +1,70 @@
+package treebank
+
+import "strings"
+
+// SyntheticPrefix starts the label of nodes added by Binarize to split up nodes with more than two children
+// The node "@NP" holds the remaining children of an "NP".
+const SyntheticPrefix = "@"
+
+// UnarySeparator joins the labels of a chain of single-child nodes collapsed by Binarize
+// The chain (S (VP (V go))) becomes (S+VP+V go).
+const UnarySeparator = "+"
+
+// IsSynthetic reports whether a label was introduced by Binarize
+func IsSynthetic(label string) bool {
+	return strings.HasPrefix(label, SyntheticPrefix)
+}
+
+// Binarize returns a copy of the tree in chomsky normal form
+// Chains of single-child nodes are collapsed into one node, joining their labels with UnarySeparator.
+// Nodes with more than two children keep their first child, and push the rest down into a SyntheticPrefix node.
+func Binarize(tree *Tree) *Tree {
+	label := tree.Label
+	for len(tree.Children) == 1 {
+		tree = tree.Children[0]
+		label = label + UnarySeparator + tree.Label
+	}
+	if tree.IsPreterminal() {
+		return &Tree{Label: label, Word: tree.Word}
+	}
+	return binarizeChildren(label, tree.Label, tree.Children)
+}
+
+// binarizeChildren builds a binary node for the children, splitting off synthetic nodes named after syntheticBase
+func binarizeChildren(label string, syntheticBase string, children []*Tree) *Tree {
+	left := Binarize(children[0])
+	if len(children) == 2 {
+		return &Tree{Label: label, Children: []*Tree{left, Binarize(children[1])}}
+	}
+	right := binarizeChildren(SyntheticPrefix+syntheticBase, syntheticBase, children[1:])
+	return &Tree{Label: label, Children: []*Tree{left, right}}
+}
+
+// Debinarize undoes Binarize, returning a copy of the tree
+// Synthetic nodes are replaced by their children, and collapsed labels are expanded back into chains.
+func Debinarize(tree *Tree) *Tree {
+	labels := strings.Split(tree.Label, UnarySeparator)
+	var node *Tree
+	if tree.IsPreterminal() {
+		node = &Tree{Label: labels[len(labels)-1], Word: tree.Word}
+	} else {
+		node = &Tree{Label: labels[len(labels)-1], Children: debinarizeChildren(tree.Children)}
+	}
+	for labelIndex := len(labels) - 2; labelIndex >= 0; labelIndex-- {
+		node = &Tree{Label: labels[labelIndex], Children: []*Tree{node}}
+	}
+	return node
+}
+
+// debinarizeChildren debinarizes each child, splicing the children of synthetic nodes into the list
+func debinarizeChildren(children []*Tree) []*Tree {
+	debinarized := []*Tree{}
+	for _, child := range children {
+		if IsSynthetic(child.Label) && !child.IsPreterminal() {
+			debinarized = append(debinarized, debinarizeChildren(child.Children)...)
+			continue
+		}
+		debinarized = append(debinarized, Debinarize(child))
+	}
+	return debinarized
+}
@@ -0,0 +1,40 @@
+package treebank
+
+import "testing"
+
+func TestBinarize(t *testing.T) {
+	type test struct {
+		tree              string
+		expectedBinarized string
+	}
+
+	testCases := []test{
+		{tree: "(S (N dogs) (V bark))", expectedBinarized: "(S (N dogs) (V bark))"},
+		{tree: "(NP (DT the) (JJ big) (JJ gray) (N dog))", expectedBinarized: "(NP (DT the) (@NP (JJ big) (@NP (JJ gray) (N dog))))"},
+		{tree: "(S (NP (N dogs)) (VP (V bark)))", expectedBinarized: "(S (NP+N dogs) (VP+V bark))"},
+		{tree: "(S (VP (V see) (N dogs) (N run)))", expectedBinarized: "(S+VP (V see) (@VP (N dogs) (N run)))"},
+	}
+
+	for _, testCase := range testCases {
+		tree, err := ParseTree(testCase.tree)
+		if err != nil {
+			t.Fatalf("(Test \"%s\"), unexpected error %v", testCase.tree, err)
+		}
+		binarized := Binarize(tree)
+		if binarized.String() != testCase.expectedBinarized {
+			t.Errorf("(Test \"%s\"), expected binarized %s, got %s", testCase.tree, testCase.expectedBinarized, binarized.String())
+		}
+		if debinarized := Debinarize(binarized); debinarized.String() != testCase.tree {
+			t.Errorf("(Test \"%s\"), expected debinarized %s, got %s", testCase.tree, testCase.tree, debinarized.String())
+		}
+	}
+}
+
+func TestIsSynthetic(t *testing.T) {
+	if !IsSynthetic("@NP") {
+		t.Errorf("@NP should be synthetic")
+	}
+	if IsSynthetic("NP") {
+		t.Errorf("NP should not be synthetic")
+	}
+}
@@ -0,0 +1,96 @@
+package treebank
+
+import (
+	"sort"
+
+	"github.com/kstafford3/gocky"
+)
+
+// Induce builds a weighted grammar from the rules used in the trees
+// Each tree is binarized, then every rule is given its relative frequency among the rules sharing its key.
+// Terminal productions gather every word seen under a key into one production.
+// Productions are sorted by key, with each key's terminal production before its non-terminal productions.
+func Induce(trees []*Tree) gocky.Grammar {
+	counts := newRuleCounts()
+	for _, tree := range trees {
+		counts.add(Binarize(tree))
+	}
+	return counts.grammar()
+}
+
+// ruleCounts counts how often each rule is used
+type ruleCounts struct {
+	keys         map[string]float64
+	nonterminals map[string]map[[2]string]float64
+	nominals     map[string]map[string]float64
+}
+
+// newRuleCounts creates an empty set of counts
+func newRuleCounts() *ruleCounts {
+	return &ruleCounts{
+		keys:         map[string]float64{},
+		nonterminals: map[string]map[[2]string]float64{},
+		nominals:     map[string]map[string]float64{},
+	}
+}
+
+// add counts every rule in a binarized tree
+func (c *ruleCounts) add(tree *Tree) {
+	c.keys[tree.Label]++
+	if tree.IsPreterminal() {
+		if c.nominals[tree.Label] == nil {
+			c.nominals[tree.Label] = map[string]float64{}
+		}
+		c.nominals[tree.Label][tree.Word]++
+		return
+	}
+	if c.nonterminals[tree.Label] == nil {
+		c.nonterminals[tree.Label] = map[[2]string]float64{}
+	}
+	c.nonterminals[tree.Label][[2]string{tree.Children[0].Label, tree.Children[1].Label}]++
+	for _, child := range tree.Children {
+		c.add(child)
+	}
+}
+
+// grammar turns the counts into relative frequency productions
+func (c *ruleCounts) grammar() gocky.Grammar {
+	keys := make([]string, 0, len(c.keys))
+	for key := range c.keys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	grammar := gocky.Grammar{}
+	for _, key := range keys {
+		total := c.keys[key]
+		if nominalCounts, ok := c.nominals[key]; ok {
+			nominals := make([]string, 0, len(nominalCounts))
+			for nominal := range nominalCounts {
+				nominals = append(nominals, nominal)
+			}
+			sort.Strings(nominals)
+			probabilities := make([]float64, len(nominals))
+			for nominalIndex, nominal := range nominals {
+				probabilities[nominalIndex] = nominalCounts[nominal] / total
+			}
+			grammar = append(grammar, gocky.WeightedTerminalProduction(key, nominals, probabilities))
+		}
+
+		ruleCounts := c.nonterminals[key]
+		rules := make([][2]string, 0, len(ruleCounts))
+		for rule := range ruleCounts {
+			rules = append(rules, rule)
+		}
+		sort.Slice(rules, func(i, j int) bool {
+			if rules[i][0] != rules[j][0] {
+				return rules[i][0] < rules[j][0]
+			}
+			return rules[i][1] < rules[j][1]
+		})
+		for _, rule := range rules {
+			grammar = append(grammar, gocky.WeightedNonterminalProduction(key, rule[0], rule[1], ruleCounts[rule]/total))
+		}
+	}
+	return grammar
+}
@@ -0,0 +1,64 @@
+package treebank
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kstafford3/gocky"
+)
+
+func TestInduce(t *testing.T) {
+	text := `
+		(S (NP (DT the) (N dog)) (V barks))
+		(S (NP (DT a) (N dog)) (V barks))
+		(S (NP (DT the) (JJ big) (N cat)) (V sleeps))
+		(S (N dogs) (V bark))
+	`
+	trees, err := Read(strings.NewReader(text))
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	grammar := Induce(trees)
+
+	type test struct {
+		sentence               []string
+		expectedProductionKeys []string
+		expectedProbability    float64
+	}
+
+	testCases := []test{
+		{
+			sentence:               []string{"the", "dog", "barks"},
+			expectedProductionKeys: []string{"S", "NP", "DT", "N", "V"},
+			// S -> NP V (3/4), NP -> DT N (2/3), DT -> the (2/3), N -> dog (2/4), V -> barks (2/4)
+			expectedProbability: 3.0 / 4.0 * 2.0 / 3.0 * 2.0 / 3.0 * 2.0 / 4.0 * 2.0 / 4.0,
+		},
+		{
+			sentence:               []string{"the", "big", "cat", "sleeps"},
+			expectedProductionKeys: []string{"S", "NP", "DT", "@NP", "JJ", "N", "V"},
+			// S -> NP V (3/4), NP -> DT @NP (1/3), DT -> the (2/3), @NP -> JJ N (1), JJ -> big (1), N -> cat (1/4), V -> sleeps (1/4)
+			expectedProbability: 3.0 / 4.0 * 1.0 / 3.0 * 2.0 / 3.0 * 1.0 / 4.0 * 1.0 / 4.0,
+		},
+		{
+			sentence:               []string{"dogs", "bark"},
+			expectedProductionKeys: []string{"S", "N", "V"},
+			// S -> N V (1/4), N -> dogs (1/4), V -> bark (1/4)
+			expectedProbability: 1.0 / 4.0 * 1.0 / 4.0 * 1.0 / 4.0,
+		},
+	}
+
+	for _, testCase := range testCases {
+		parses := gocky.Parses(testCase.sentence, grammar)
+		if len(parses) != 1 {
+			t.Fatalf("(Test %v), expected 1 parse, got %d", testCase.sentence, len(parses))
+		}
+		if !reflect.DeepEqual(testCase.expectedProductionKeys, parses[0].ProductionKeys()) {
+			t.Errorf("(Test %v), expected production keys %v, got %v", testCase.sentence, testCase.expectedProductionKeys, parses[0].ProductionKeys())
+		}
+		if math.Abs(testCase.expectedProbability-parses[0].Probability()) > 1e-12 {
+			t.Errorf("(Test %v), expected probability %f, got %f", testCase.sentence, testCase.expectedProbability, parses[0].Probability())
+		}
+	}
+}
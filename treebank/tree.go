@@ -0,0 +1,184 @@
+// Package treebank reads bracketed parse trees and induces gocky grammars from them.
+//
+// Trees are written in the bracketed format used by the Penn Treebank:
+//
+//	(S (NP (DT the) (N dog)) (V barks))
+//
+// A node with a single word is a preterminal, which becomes a terminal production.
+// A node with several words, such as (N New York), is a preterminal for a multi-word nominal.
+package treebank
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+
+	"github.com/kstafford3/gocky"
+)
+
+// Tree is a node in a bracketed parse tree
+// Preterminal nodes have a Word and no Children.
+type Tree struct {
+	Label    string
+	Word     string
+	Children []*Tree
+}
+
+// FromParse converts a parse into a tree, labelling each node with its production key
+func FromParse(parse *gocky.Parse) *Tree {
+	if parse.Left() == nil && parse.Right() == nil {
+		return &Tree{Label: parse.Key(), Word: parse.Terminal()}
+	}
+	tree := &Tree{Label: parse.Key()}
+	for _, child := range []*gocky.Parse{parse.Left(), parse.Right()} {
+		if child != nil {
+			tree.Children = append(tree.Children, FromParse(child))
+		}
+	}
+	return tree
+}
+
+// IsPreterminal reports whether the node labels a word, or the words of a multi-word nominal
+func (t *Tree) IsPreterminal() bool {
+	return len(t.Children) == 0
+}
+
+// Words returns the words under the node in order
+// A preterminal whose Word holds several words separated by spaces, such as "New York", contributes each of them.
+func (t *Tree) Words() []string {
+	if t.IsPreterminal() {
+		if words := strings.Fields(t.Word); len(words) > 1 {
+			return words
+		}
+		return []string{t.Word}
+	}
+	words := []string{}
+	for _, child := range t.Children {
+		words = append(words, child.Words()...)
+	}
+	return words
+}
+
+// String writes the tree in bracketed format
+func (t *Tree) String() string {
+	builder := &strings.Builder{}
+	t.write(builder)
+	return builder.String()
+}
+
+// write adds the bracketed format of the tree to the builder
+func (t *Tree) write(builder *strings.Builder) {
+	builder.WriteString("(")
+	builder.WriteString(t.Label)
+	if t.IsPreterminal() {
+		builder.WriteString(" ")
+		builder.WriteString(t.Word)
+	}
+	for _, child := range t.Children {
+		builder.WriteString(" ")
+		child.write(builder)
+	}
+	builder.WriteString(")")
+}
+
+// ParseTree reads a single bracketed tree from a string
+func ParseTree(text string) (*Tree, error) {
+	trees, err := Read(strings.NewReader(text))
+	if err != nil {
+		return nil, err
+	}
+	if len(trees) != 1 {
+		return nil, fmt.Errorf("treebank: expected 1 tree, found %d", len(trees))
+	}
+	return trees[0], nil
+}
+
+// Read reads every bracketed tree from the reader
+// Trees may span several lines, and an unlabelled outer bracket around a single tree is removed.
+func Read(reader io.Reader) ([]*Tree, error) {
+	tokens, err := tokenize(reader)
+	if err != nil {
+		return nil, err
+	}
+	trees := []*Tree{}
+	for position := 0; position < len(tokens); {
+		tree, next, err := readTree(tokens, position)
+		if err != nil {
+			return nil, err
+		}
+		if len(tree.Label) == 0 && len(tree.Children) == 1 {
+			tree = tree.Children[0]
+		}
+		trees = append(trees, tree)
+		position = next
+	}
+	return trees, nil
+}
+
+// tokenize splits bracketed text into brackets and atoms
+func tokenize(reader io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	tokens := []string{}
+	for scanner.Scan() {
+		atom := []rune{}
+		for _, character := range scanner.Text() {
+			if character == '(' || character == ')' || unicode.IsSpace(character) {
+				if len(atom) > 0 {
+					tokens = append(tokens, string(atom))
+					atom = atom[:0]
+				}
+				if !unicode.IsSpace(character) {
+					tokens = append(tokens, string(character))
+				}
+				continue
+			}
+			atom = append(atom, character)
+		}
+		if len(atom) > 0 {
+			tokens = append(tokens, string(atom))
+		}
+	}
+	return tokens, scanner.Err()
+}
+
+// readTree reads the tree starting at position and returns it with the position after it
+func readTree(tokens []string, position int) (*Tree, int, error) {
+	if tokens[position] != "(" {
+		return nil, 0, fmt.Errorf("treebank: expected \"(\" but found %q", tokens[position])
+	}
+	position++
+	tree := &Tree{}
+	if position < len(tokens) && tokens[position] != "(" && tokens[position] != ")" {
+		tree.Label = tokens[position]
+		position++
+	}
+	words := []string{}
+	for position < len(tokens) && tokens[position] != ")" {
+		if tokens[position] == "(" {
+			child, next, err := readTree(tokens, position)
+			if err != nil {
+				return nil, 0, err
+			}
+			tree.Children = append(tree.Children, child)
+			position = next
+			continue
+		}
+		words = append(words, tokens[position])
+		position++
+	}
+	if position == len(tokens) {
+		return nil, 0, fmt.Errorf("treebank: missing \")\" for %q", tree.Label)
+	}
+	switch {
+	case len(words) > 0 && len(tree.Children) == 0:
+		tree.Word = strings.Join(words, " ")
+	case len(words) > 0:
+		return nil, 0, fmt.Errorf("treebank: %q mixes words with other nodes", tree.Label)
+	case len(tree.Children) == 0:
+		return nil, 0, fmt.Errorf("treebank: %q is empty", tree.Label)
+	}
+	return tree, position + 1, nil
+}
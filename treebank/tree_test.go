@@ -0,0 +1,106 @@
+package treebank
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kstafford3/gocky"
+)
+
+func TestParseTree(t *testing.T) {
+	type test struct {
+		name          string
+		text          string
+		expectedTree  string
+		expectedWords []string
+	}
+
+	testCases := []test{
+		{
+			name:          "simple",
+			text:          "(S (NP (DT the) (N dog)) (V barks))",
+			expectedTree:  "(S (NP (DT the) (N dog)) (V barks))",
+			expectedWords: []string{"the", "dog", "barks"},
+		},
+		{
+			name:          "outer bracket",
+			text:          "( (S (N dogs) (V bark)) )",
+			expectedTree:  "(S (N dogs) (V bark))",
+			expectedWords: []string{"dogs", "bark"},
+		},
+		{
+			name:          "multiline",
+			text:          "(S\n  (N dogs)\n  (VP (V chase)\n      (N cats)))",
+			expectedTree:  "(S (N dogs) (VP (V chase) (N cats)))",
+			expectedWords: []string{"dogs", "chase", "cats"},
+		},
+		{
+			name:          "multi-word nominal",
+			text:          "(S (N New York) (V sleeps))",
+			expectedTree:  "(S (N New York) (V sleeps))",
+			expectedWords: []string{"New", "York", "sleeps"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		tree, err := ParseTree(testCase.text)
+		if err != nil {
+			t.Fatalf("(Test \"%s\"), unexpected error %v", testCase.name, err)
+		}
+		if tree.String() != testCase.expectedTree {
+			t.Errorf("(Test \"%s\"), expected tree %s, got %s", testCase.name, testCase.expectedTree, tree.String())
+		}
+		if !reflect.DeepEqual(testCase.expectedWords, tree.Words()) {
+			t.Errorf("(Test \"%s\"), expected words %v, got %v", testCase.name, testCase.expectedWords, tree.Words())
+		}
+	}
+}
+
+func TestParseTreeErrors(t *testing.T) {
+	testCases := []string{
+		"(S (N dogs) (V bark)",
+		"(S dogs (V bark))",
+		"(S)",
+		"S (N dogs)",
+		"(S (N dogs)) (S (N cats))",
+	}
+
+	for _, testCase := range testCases {
+		if _, err := ParseTree(testCase); err == nil {
+			t.Errorf("(Test \"%s\"), expected an error", testCase)
+		}
+	}
+}
+
+func TestRead(t *testing.T) {
+	text := "(S (N dogs) (V bark))\n(S (N cats)\n(V meow))\n"
+	trees, err := Read(strings.NewReader(text))
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if len(trees) != 2 {
+		t.Fatalf("Expected 2 trees, got %d", len(trees))
+	}
+	if trees[1].String() != "(S (N cats) (V meow))" {
+		t.Errorf("Expected second tree (S (N cats) (V meow)), got %s", trees[1].String())
+	}
+}
+
+func TestFromParse(t *testing.T) {
+	grammar := gocky.Grammar{
+		gocky.TerminalProduction("DT", []string{"the"}),
+		gocky.TerminalProduction("N", []string{"dog"}),
+		gocky.TerminalProduction("V", []string{"barks"}),
+		gocky.NonterminalProduction("NP", "DT", "N"),
+		gocky.NonterminalProduction("S", "NP", "V"),
+	}
+	parses := gocky.Parses([]string{"the", "dog", "barks"}, grammar)
+	if len(parses) != 1 {
+		t.Fatalf("Expected 1 parse, got %d", len(parses))
+	}
+	expectedTree := "(S (NP (DT the) (N dog)) (V barks))"
+	if tree := FromParse(&parses[0]); tree.String() != expectedTree {
+		t.Errorf("Expected tree %s, got %s", expectedTree, tree.String())
+	}
+}
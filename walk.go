@@ -0,0 +1,131 @@
+package gocky
+
+// WalkAction tells Walk how to continue after visiting a node
+type WalkAction int
+
+const (
+	// WalkContinue carries on to the next node
+	WalkContinue WalkAction = iota
+	// WalkSkipChildren carries on without visiting the components of this node
+	// It has no effect in WalkPostOrder, where the components have already been visited.
+	WalkSkipChildren
+	// WalkStop ends the walk without visiting any more nodes
+	WalkStop
+)
+
+// WalkFunc is called for each node of a parse, with the node's depth below the root
+// The root of the parse has a depth of 0.
+type WalkFunc func(node *Parse, depth int) WalkAction
+
+// Walk visits every node of the parse in pre-order, visiting each node before its left and then right components
+func Walk(parse *Parse, visit WalkFunc) {
+	walkPreOrder(parse, 0, visit)
+}
+
+// WalkPostOrder visits every node of the parse in post-order, visiting the left and then right components before each node
+func WalkPostOrder(parse *Parse, visit WalkFunc) {
+	walkPostOrder(parse, 0, visit)
+}
+
+// walkPreOrder visits the node then its components, returning false once the walk should stop
+func walkPreOrder(node *Parse, depth int, visit WalkFunc) bool {
+	if node == nil {
+		return true
+	}
+	switch visit(node, depth) {
+	case WalkStop:
+		return false
+	case WalkSkipChildren:
+		return true
+	}
+	return walkPreOrder(node.left, depth+1, visit) && walkPreOrder(node.right, depth+1, visit)
+}
+
+// walkPostOrder visits the components of the node then the node, returning false once the walk should stop
+func walkPostOrder(node *Parse, depth int, visit WalkFunc) bool {
+	if node == nil {
+		return true
+	}
+	if !walkPostOrder(node.left, depth+1, visit) || !walkPostOrder(node.right, depth+1, visit) {
+		return false
+	}
+	return visit(node, depth) != WalkStop
+}
+
+// NodeIterator steps through some of the nodes of a parse in pre-order
+// Use Next to advance, then Node and Depth to read the current node.
+//
+//	leaves := parse.LeafNodes()
+//	for leaves.Next() {
+//		fmt.Println(leaves.Node().Terminal())
+//	}
+type NodeIterator struct {
+	stack   []iteratorEntry
+	current iteratorEntry
+	include func(node *Parse) bool
+}
+
+// iteratorEntry is a node waiting to be visited by a NodeIterator
+type iteratorEntry struct {
+	node  *Parse
+	depth int
+}
+
+// newNodeIterator creates a NodeIterator over the nodes of the parse accepted by include
+func newNodeIterator(parse *Parse, include func(node *Parse) bool) *NodeIterator {
+	iterator := &NodeIterator{include: include}
+	if parse != nil {
+		iterator.stack = []iteratorEntry{{node: parse}}
+	}
+	return iterator
+}
+
+// Nodes returns an iterator over every node of the parse
+func (p *Parse) Nodes() *NodeIterator {
+	return newNodeIterator(p, func(node *Parse) bool {
+		return true
+	})
+}
+
+// LeafNodes returns an iterator over the terminal nodes of the parse, from left to right
+func (p *Parse) LeafNodes() *NodeIterator {
+	return newNodeIterator(p, func(node *Parse) bool {
+		return node.left == nil && node.right == nil
+	})
+}
+
+// InternalNodes returns an iterator over the non-terminal nodes of the parse
+func (p *Parse) InternalNodes() *NodeIterator {
+	return newNodeIterator(p, func(node *Parse) bool {
+		return node.left != nil || node.right != nil
+	})
+}
+
+// Next advances to the next node, returning false once there are no nodes left
+func (it *NodeIterator) Next() bool {
+	for len(it.stack) > 0 {
+		entry := it.stack[len(it.stack)-1]
+		it.stack = it.stack[:len(it.stack)-1]
+		for _, child := range []*Parse{entry.node.right, entry.node.left} {
+			if child != nil {
+				it.stack = append(it.stack, iteratorEntry{node: child, depth: entry.depth + 1})
+			}
+		}
+		if it.include(entry.node) {
+			it.current = entry
+			return true
+		}
+	}
+	it.current = iteratorEntry{}
+	return false
+}
+
+// Node returns the current node
+func (it *NodeIterator) Node() *Parse {
+	return it.current.node
+}
+
+// Depth returns the depth of the current node below the root of the parse
+func (it *NodeIterator) Depth() int {
+	return it.current.depth
+}
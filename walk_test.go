@@ -0,0 +1,130 @@
+package gocky
+
+import (
+	"reflect"
+	"testing"
+)
+
+// walkedNode records a visit from Walk
+type walkedNode struct {
+	key   string
+	depth int
+}
+
+func TestWalk(t *testing.T) {
+	type test struct {
+		name          string
+		postOrder     bool
+		action        func(node *Parse) WalkAction
+		expectedNodes []walkedNode
+	}
+
+	testCases := []test{
+		{
+			name:   "pre-order",
+			action: func(node *Parse) WalkAction { return WalkContinue },
+			expectedNodes: []walkedNode{
+				{"S3", 0}, {"DN0", 1}, {"DT", 2}, {"N", 2}, {"VP2", 1}, {"V", 2}, {"NP0", 2}, {"N", 3}, {"CCN", 3}, {"CC", 4}, {"N", 4},
+			},
+		},
+		{
+			name:      "post-order",
+			postOrder: true,
+			action:    func(node *Parse) WalkAction { return WalkContinue },
+			expectedNodes: []walkedNode{
+				{"DT", 2}, {"N", 2}, {"DN0", 1}, {"V", 2}, {"N", 3}, {"CC", 4}, {"N", 4}, {"CCN", 3}, {"NP0", 2}, {"VP2", 1}, {"S3", 0},
+			},
+		},
+		{
+			name: "skip children",
+			action: func(node *Parse) WalkAction {
+				if node.Key() == "DN0" || node.Key() == "NP0" {
+					return WalkSkipChildren
+				}
+				return WalkContinue
+			},
+			expectedNodes: []walkedNode{{"S3", 0}, {"DN0", 1}, {"VP2", 1}, {"V", 2}, {"NP0", 2}},
+		},
+		{
+			name: "stop",
+			action: func(node *Parse) WalkAction {
+				if node.Key() == "V" {
+					return WalkStop
+				}
+				return WalkContinue
+			},
+			expectedNodes: []walkedNode{{"S3", 0}, {"DN0", 1}, {"DT", 2}, {"N", 2}, {"VP2", 1}, {"V", 2}},
+		},
+		{
+			name:      "post-order stop",
+			postOrder: true,
+			action: func(node *Parse) WalkAction {
+				if node.Key() == "DN0" {
+					return WalkStop
+				}
+				return WalkContinue
+			},
+			expectedNodes: []walkedNode{{"DT", 2}, {"N", 2}, {"DN0", 1}},
+		},
+	}
+
+	parse := &Parses([]string{"the", "panda", "eats", "shoots", "and", "leaves"}, panda())[0]
+	for _, testCase := range testCases {
+		actualNodes := []walkedNode{}
+		visit := func(node *Parse, depth int) WalkAction {
+			actualNodes = append(actualNodes, walkedNode{node.Key(), depth})
+			return testCase.action(node)
+		}
+		if testCase.postOrder {
+			WalkPostOrder(parse, visit)
+		} else {
+			Walk(parse, visit)
+		}
+		if !reflect.DeepEqual(testCase.expectedNodes, actualNodes) {
+			t.Errorf("(Test \"%s\"), expected nodes %v, got %v", testCase.name, testCase.expectedNodes, actualNodes)
+		}
+	}
+}
+
+func TestNodeIterators(t *testing.T) {
+	type test struct {
+		name          string
+		iterator      func(parse *Parse) *NodeIterator
+		expectedNodes []walkedNode
+	}
+
+	testCases := []test{
+		{
+			name:     "nodes",
+			iterator: (*Parse).Nodes,
+			expectedNodes: []walkedNode{
+				{"S3", 0}, {"DN0", 1}, {"DT", 2}, {"N", 2}, {"VP2", 1}, {"V", 2}, {"NP0", 2}, {"N", 3}, {"CCN", 3}, {"CC", 4}, {"N", 4},
+			},
+		},
+		{
+			name:          "leaves",
+			iterator:      (*Parse).LeafNodes,
+			expectedNodes: []walkedNode{{"DT", 2}, {"N", 2}, {"V", 2}, {"N", 3}, {"CC", 4}, {"N", 4}},
+		},
+		{
+			name:          "internal",
+			iterator:      (*Parse).InternalNodes,
+			expectedNodes: []walkedNode{{"S3", 0}, {"DN0", 1}, {"VP2", 1}, {"NP0", 2}, {"CCN", 3}},
+		},
+	}
+
+	parse := &Parses([]string{"the", "panda", "eats", "shoots", "and", "leaves"}, panda())[0]
+	for _, testCase := range testCases {
+		actualNodes := []walkedNode{}
+		iterator := testCase.iterator(parse)
+		for iterator.Next() {
+			actualNodes = append(actualNodes, walkedNode{iterator.Node().Key(), iterator.Depth()})
+		}
+		if !reflect.DeepEqual(testCase.expectedNodes, actualNodes) {
+			t.Errorf("(Test \"%s\"), expected nodes %v, got %v", testCase.name, testCase.expectedNodes, actualNodes)
+		}
+		if iterator.Next() || iterator.Node() != nil {
+			t.Errorf("(Test \"%s\"), a finished iterator should stay finished", testCase.name)
+		}
+	}
+}